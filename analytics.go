@@ -0,0 +1,303 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"github.com/olion500/gopherlol/internal/analytics"
+	"io"
+	"os"
+	"sort"
+	"time"
+)
+
+// ANSI color codes
+const (
+	ColorReset  = "\033[0m"
+	ColorRed    = "\033[31m"
+	ColorGreen  = "\033[32m"
+	ColorYellow = "\033[33m"
+	ColorBlue   = "\033[34m"
+	ColorPurple = "\033[35m"
+	ColorCyan   = "\033[36m"
+	ColorWhite  = "\033[37m"
+	ColorBold   = "\033[1m"
+	ColorDim    = "\033[2m"
+)
+
+// runAnalytics prints usage statistics from the usage log: today's by
+// default, or those of a date, a date range or all time
+func runAnalytics(args []string, getenv func(string) string, out, errOut io.Writer) int {
+	logFile := "usage.log"
+	if value := getenv("GOPHERLOL_USAGE_LOG"); value != "" {
+		logFile = value
+	}
+
+	flags := flag.NewFlagSet("analytics", flag.ContinueOnError)
+	flags.SetOutput(errOut)
+	var (
+		date      = flags.String("date", "", "Show stats for specific date (YYYY-MM-DD)")
+		startDate = flags.String("start", "", "Start date for range (YYYY-MM-DD)")
+		endDate   = flags.String("end", "", "End date for range (YYYY-MM-DD)")
+		top       = flags.Int("top", 10, "Number of top commands to show")
+		overall   = flags.Bool("overall", false, "Show overall statistics")
+	)
+	flags.StringVar(&logFile, "usage-log", logFile, "Path to usage log file (env GOPHERLOL_USAGE_LOG)")
+	flags.StringVar(&logFile, "log", logFile, "Same as -usage-log")
+	flags.Usage = func() { showHelp(errOut) }
+	if err := flags.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return 0
+		}
+		return 2
+	}
+	if flags.NArg() > 0 {
+		flags.Usage()
+		return 2
+	}
+
+	// Initialize analytics
+	usage := analytics.NewAnalytics(logFile)
+
+	// Check if log file exists
+	if _, err := os.Stat(logFile); os.IsNotExist(err) {
+		_, _ = fmt.Fprintf(out, "%s❌ No analytics data found%s\n", ColorRed, ColorReset)
+		_, _ = fmt.Fprintf(out, "   Log file '%s' does not exist.\n", logFile)
+		_, _ = fmt.Fprintf(out, "   Start using gopherlol to generate analytics data!\n\n")
+		return 0
+	}
+
+	printHeader(out)
+
+	if *overall {
+		showOverallStats(out, usage, *top)
+	} else if *startDate != "" && *endDate != "" {
+		showDateRangeStats(out, usage, *startDate, *endDate, *top)
+	} else {
+		targetDate := *date
+		if targetDate == "" {
+			targetDate = time.Now().Format("2006-01-02")
+		}
+		showDayStats(out, usage, targetDate, *top)
+	}
+	return 0
+}
+
+func showHelp(out io.Writer) {
+	_, _ = fmt.Fprintf(out, "%s📊 gopherlol Analytics CLI%s\n\n", ColorBold+ColorCyan, ColorReset)
+	_, _ = fmt.Fprintln(out, "Display command usage analytics in the terminal.")
+	_, _ = fmt.Fprintln(out)
+	_, _ = fmt.Fprintf(out, "%sUsage:%s\n", ColorBold, ColorReset)
+	_, _ = fmt.Fprintln(out, "  gopherlol analytics [options]")
+	_, _ = fmt.Fprintln(out)
+	_, _ = fmt.Fprintf(out, "%sOptions:%s\n", ColorBold, ColorReset)
+	_, _ = fmt.Fprintln(out, "  -date YYYY-MM-DD    Show stats for specific date (default: today)")
+	_, _ = fmt.Fprintln(out, "  -start YYYY-MM-DD   Start date for range analysis")
+	_, _ = fmt.Fprintln(out, "  -end YYYY-MM-DD     End date for range analysis")
+	_, _ = fmt.Fprintln(out, "  -usage-log FILE     Path to usage log file (default: usage.log)")
+	_, _ = fmt.Fprintln(out, "  -top N              Number of top commands to show (default: 10)")
+	_, _ = fmt.Fprintln(out, "  -overall            Show overall/all-time statistics")
+	_, _ = fmt.Fprintln(out, "  -help               Show this help message")
+	_, _ = fmt.Fprintln(out)
+	_, _ = fmt.Fprintf(out, "%sExamples:%s\n", ColorBold, ColorReset)
+	_, _ = fmt.Fprintln(out, "  gopherlol analytics                    # Today's stats")
+	_, _ = fmt.Fprintln(out, "  gopherlol analytics -date 2024-01-15   # Specific date")
+	_, _ = fmt.Fprintln(out, "  gopherlol analytics -overall           # All-time stats")
+	_, _ = fmt.Fprintln(out, "  gopherlol analytics -start 2024-01-01 -end 2024-01-31  # Range")
+	_, _ = fmt.Fprintln(out, "  gopherlol analytics -top 5             # Show top 5 commands only")
+}
+
+func printHeader(out io.Writer) {
+	_, _ = fmt.Fprintf(out, "%s", ColorBold+ColorCyan)
+	_, _ = fmt.Fprintln(out, "┌─────────────────────────────────────────────────┐")
+	_, _ = fmt.Fprintln(out, "│           🔍 gopherlol Analytics                │")
+	_, _ = fmt.Fprintln(out, "└─────────────────────────────────────────────────┘")
+	_, _ = fmt.Fprintf(out, "%s\n", ColorReset)
+}
+
+func showDayStats(out io.Writer, usage *analytics.Analytics, date string, topCount int) {
+	stats, err := usage.GetDayStats(date)
+	if err != nil {
+		_, _ = fmt.Fprintf(out, "%sError: %v%s\n", ColorRed, err, ColorReset)
+		return
+	}
+
+	_, _ = fmt.Fprintf(out, "%s📅 Statistics for %s%s\n\n", ColorBold+ColorBlue, date, ColorReset)
+
+	if stats.TotalUsage == 0 {
+		_, _ = fmt.Fprintf(out, "%s📭 No command usage data for this date%s\n", ColorYellow, ColorReset)
+		return
+	}
+
+	showStatsOverview(out, stats)
+	showTopCommands(out, stats.TopCommands, stats.AvgDuration, topCount)
+}
+
+func showDateRangeStats(out io.Writer, usage *analytics.Analytics, startDate, endDate string, topCount int) {
+	rangeStats, err := usage.GetDateRange(startDate, endDate)
+	if err != nil {
+		_, _ = fmt.Fprintf(out, "%sError: %v%s\n", ColorRed, err, ColorReset)
+		return
+	}
+
+	if len(rangeStats) == 0 {
+		_, _ = fmt.Fprintf(out, "%s📭 No data found for the specified date range%s\n", ColorYellow, ColorReset)
+		return
+	}
+
+	// Aggregate range data
+	totalUsage := 0
+	totalTime := int64(0)
+	allCommands := make(map[string]int)
+	allDurations := make(map[string][]int64)
+	maxUsers := 0
+
+	for _, dayStats := range rangeStats {
+		totalUsage += dayStats.TotalUsage
+		totalTime += dayStats.TotalTime
+		if dayStats.UniqueUsers > maxUsers {
+			maxUsers = dayStats.UniqueUsers
+		}
+
+		for cmd, count := range dayStats.Commands {
+			allCommands[cmd] += count
+		}
+
+		// Collect duration data (simplified aggregation)
+		for cmd, avgDur := range dayStats.AvgDuration {
+			if avgDur > 0 {
+				allDurations[cmd] = append(allDurations[cmd], int64(avgDur))
+			}
+		}
+	}
+
+	// Calculate average durations
+	avgDurations := make(map[string]float64)
+	for cmd, durations := range allDurations {
+		if len(durations) > 0 {
+			var total int64
+			for _, d := range durations {
+				total += d
+			}
+			avgDurations[cmd] = float64(total) / float64(len(durations))
+		}
+	}
+
+	// Create aggregated stats
+	aggregatedStats := &analytics.DayStats{
+		Date:        fmt.Sprintf("%s to %s", startDate, endDate),
+		TotalUsage:  totalUsage,
+		Commands:    allCommands,
+		AvgDuration: avgDurations,
+		TotalTime:   totalTime,
+		UniqueUsers: maxUsers,
+		TopCommands: getTopCommandsFromMap(allCommands, topCount),
+	}
+
+	_, _ = fmt.Fprintf(out, "%s📊 Statistics for %s%s\n\n", ColorBold+ColorBlue, aggregatedStats.Date, ColorReset)
+	showStatsOverview(out, aggregatedStats)
+	showTopCommands(out, aggregatedStats.TopCommands, aggregatedStats.AvgDuration, topCount)
+}
+
+func showOverallStats(out io.Writer, usage *analytics.Analytics, topCount int) {
+	stats, err := usage.GetOverallStats()
+	if err != nil {
+		_, _ = fmt.Fprintf(out, "%sError: %v%s\n", ColorRed, err, ColorReset)
+		return
+	}
+
+	_, _ = fmt.Fprintf(out, "%s🌟 Overall Statistics (All Time)%s\n\n", ColorBold+ColorPurple, ColorReset)
+
+	if stats.TotalUsage == 0 {
+		_, _ = fmt.Fprintf(out, "%s📭 No command usage data found%s\n", ColorYellow, ColorReset)
+		return
+	}
+
+	showStatsOverview(out, stats)
+	showTopCommands(out, stats.TopCommands, stats.AvgDuration, topCount)
+}
+
+func showStatsOverview(out io.Writer, stats *analytics.DayStats) {
+	totalTimeHours := float64(stats.TotalTime) / (1000 * 60 * 60)
+
+	_, _ = fmt.Fprintf(out, "%s┌─ Overview ─────────────────────────────────────┐%s\n", ColorDim, ColorReset)
+	_, _ = fmt.Fprintf(out, "│ %s📊 Total Usage:%s     %-25d │\n", ColorGreen, ColorReset, stats.TotalUsage)
+	_, _ = fmt.Fprintf(out, "│ %s👥 Unique Users:%s    %-25d │\n", ColorCyan, ColorReset, stats.UniqueUsers)
+	_, _ = fmt.Fprintf(out, "│ %s⏱️  Total Time:%s     %-22.1fh │\n", ColorYellow, ColorReset, totalTimeHours)
+
+	if len(stats.TopCommands) > 0 {
+		topCmd := stats.TopCommands[0]
+		_, _ = fmt.Fprintf(out, "│ %s🔝 Top Command:%s     %-15s (%d uses) │\n", ColorPurple, ColorReset, topCmd.Command, topCmd.Count)
+	}
+
+	_, _ = fmt.Fprintf(out, "%s└───────────────────────────────────────────────┘%s\n\n", ColorDim, ColorReset)
+}
+
+func showTopCommands(out io.Writer, commands []analytics.CommandCount, avgDurations map[string]float64, limit int) {
+	if len(commands) == 0 {
+		return
+	}
+
+	if len(commands) > limit {
+		commands = commands[:limit]
+	}
+
+	_, _ = fmt.Fprintf(out, "%s📈 Top Commands%s\n", ColorBold+ColorBlue, ColorReset)
+	_, _ = fmt.Fprintf(out, "%s┌─────────────────────────────────────────────────────────────┐%s\n", ColorDim, ColorReset)
+	_, _ = fmt.Fprintf(out, "│ %s%-15s %8s %12s %12s%s │\n", ColorBold, "Command", "Count", "Percentage", "Avg Duration", ColorReset)
+	_, _ = fmt.Fprintf(out, "├─────────────────────────────────────────────────────────────┤\n")
+
+	// Calculate total for percentages
+	total := 0
+	for _, cmd := range commands {
+		total += cmd.Count
+	}
+
+	for i, cmd := range commands {
+		percentage := float64(cmd.Count) / float64(total) * 100
+		avgDur := avgDurations[cmd.Command]
+
+		// Color coding for ranking
+		rankColor := ColorWhite
+		switch i {
+		case 0:
+			rankColor = ColorGreen + ColorBold
+		case 1:
+			rankColor = ColorYellow + ColorBold
+		case 2:
+			rankColor = ColorRed + ColorBold
+		}
+
+		durationStr := "-"
+		if avgDur > 0 {
+			if avgDur < 1000 {
+				durationStr = fmt.Sprintf("%.0fms", avgDur)
+			} else {
+				durationStr = fmt.Sprintf("%.1fs", avgDur/1000)
+			}
+		}
+
+		_, _ = fmt.Fprintf(out, "│ %s%-15s%s %7d %11.1f%% %11s │\n",
+			rankColor, cmd.Command, ColorReset, cmd.Count, percentage, durationStr)
+	}
+
+	_, _ = fmt.Fprintf(out, "%s└─────────────────────────────────────────────────────────────┘%s\n", ColorDim, ColorReset)
+}
+
+func getTopCommandsFromMap(commands map[string]int, limit int) []analytics.CommandCount {
+	var commandList []analytics.CommandCount
+	for cmd, count := range commands {
+		commandList = append(commandList, analytics.CommandCount{Command: cmd, Count: count})
+	}
+
+	// Sort by count (descending)
+	sort.Slice(commandList, func(i, j int) bool {
+		return commandList[i].Count > commandList[j].Count
+	})
+
+	if len(commandList) > limit {
+		commandList = commandList[:limit]
+	}
+
+	return commandList
+}
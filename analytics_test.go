@@ -0,0 +1,40 @@
+package main
+
+import (
+	"bytes"
+	"github.com/olion500/gopherlol/internal/analytics"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRunAnalytics(t *testing.T) {
+	logFile := filepath.Join(t.TempDir(), "usage.log")
+	usage := analytics.NewAnalytics(logFile)
+	usage.LogCommandUsage("gh", "gh pr", "", "", false, false, "")
+	usage.LogCommandUsage("gh", "gh issues", "", "", false, false, "")
+	usage.LogCommandUsage("g", "g hello", "", "", true, false, "")
+
+	var out, errOut bytes.Buffer
+	env := envFunc(map[string]string{"GOPHERLOL_USAGE_LOG": logFile})
+	if code := runAnalytics([]string{"-overall"}, env, &out, &errOut); code != 0 {
+		t.Fatalf("Expected exit code 0, got %d: %s", code, errOut.String())
+	}
+	for _, expected := range []string{"Overall Statistics", "gh", "Top Commands"} {
+		if !strings.Contains(out.String(), expected) {
+			t.Errorf("Expected output to contain %q, got %q", expected, out.String())
+		}
+	}
+}
+
+func TestRunAnalytics_NoLog(t *testing.T) {
+	missing := filepath.Join(t.TempDir(), "missing.log")
+
+	var out, errOut bytes.Buffer
+	if code := runAnalytics([]string{"-usage-log", missing}, envFunc(nil), &out, &errOut); code != 0 {
+		t.Fatalf("Expected exit code 0, got %d: %s", code, errOut.String())
+	}
+	if !strings.Contains(out.String(), "No analytics data found") {
+		t.Errorf("Expected a missing log message, got %q", out.String())
+	}
+}
@@ -0,0 +1,124 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// subcommand is one of the commands the gopherlol binary runs
+type subcommand struct {
+	Name    string
+	Summary string
+	// Hidden commands are left out of the usage message and completions
+	Hidden bool
+	Run    func(args []string, getenv func(string) string, out, errOut io.Writer) int
+}
+
+// subcommands lists the commands in the order the usage message shows them.
+// It is filled in by init because the completion command reads it.
+var subcommands []subcommand
+
+func init() {
+	subcommands = []subcommand{
+		{Name: "serve", Summary: "run the redirect server (the default)", Run: runServe},
+		{Name: "resolve", Summary: "print or open the URL a query resolves to", Run: runResolve},
+		{Name: "analytics", Summary: "show command usage analytics", Run: runAnalytics},
+		{Name: "validate", Summary: "lint the configuration and report problems as JSON", Run: runValidate},
+		{Name: "test", Summary: "check that the example queries of every command resolve as expected", Run: runTest},
+		{Name: "import", Summary: "merge the commands of another config into a config file", Run: runImport},
+		{Name: "export", Summary: "write the merged configuration as a single file", Run: runExport},
+		{Name: "config", Summary: "convert config files between formats", Run: runConfig},
+		{Name: "completion", Summary: "print a bash, zsh or fish completion script", Run: runCompletion},
+		{Name: "__complete", Hidden: true, Run: runComplete},
+	}
+}
+
+// run dispatches to the subcommand named by the first argument and returns
+// the exit code. Without one, or when the first argument is a flag, the
+// server runs, so existing invocations keep working.
+func run(args []string, getenv func(string) string, out, errOut io.Writer) int {
+	if len(args) == 0 {
+		return runServe(args, getenv, out, errOut)
+	}
+
+	name := args[0]
+	switch {
+	case name == "help" || name == "-h" || name == "-help" || name == "--help":
+		printUsage(out)
+		return 0
+	case strings.HasPrefix(name, "-"):
+		return runServe(args, getenv, out, errOut)
+	}
+	for _, cmd := range subcommands {
+		if cmd.Name == name {
+			return cmd.Run(args[1:], getenv, out, errOut)
+		}
+	}
+
+	_, _ = fmt.Fprintf(errOut, "Unknown command %q\n\n", name)
+	printUsage(errOut)
+	return 2
+}
+
+// printUsage lists the subcommands
+func printUsage(w io.Writer) {
+	_, _ = fmt.Fprintln(w, "Usage: gopherlol <command> [flags] [arguments]")
+	_, _ = fmt.Fprintln(w)
+	_, _ = fmt.Fprintln(w, "Commands:")
+	for _, cmd := range subcommands {
+		if cmd.Hidden {
+			continue
+		}
+		_, _ = fmt.Fprintf(w, "  %-10s %s\n", cmd.Name, cmd.Summary)
+	}
+	_, _ = fmt.Fprintln(w)
+	_, _ = fmt.Fprintln(w, "Run gopherlol <command> -h for the flags of a command.")
+}
+
+// configFlags are the config-loading flags shared by every subcommand that
+// reads the command configuration
+type configFlags struct {
+	env    []string
+	files  stringList
+	format string
+}
+
+// addConfigFlags registers -config and -format on flags. GOPHERLOL_CONFIG
+// names the config files when -config is not given, separated by commas
+// since paths and URLs may contain colons.
+func addConfigFlags(flags *flag.FlagSet, getenv func(string) string) *configFlags {
+	c := &configFlags{}
+	for _, file := range strings.Split(getenv("GOPHERLOL_CONFIG"), ",") {
+		if file = strings.TrimSpace(file); file != "" {
+			c.env = append(c.env, file)
+		}
+	}
+	flags.Var(&c.files, "config", "config file or URL; repeat to layer several, later ones winning (env GOPHERLOL_CONFIG, default: system, team and user layers)")
+	flags.StringVar(&c.format, "format", "", "config format: json, yaml or toml (default: from the extension)")
+	return c
+}
+
+// configs returns the config files named by -config, or by GOPHERLOL_CONFIG
+// when there are none
+func (c *configFlags) configs() []string {
+	if len(c.files) > 0 {
+		return c.files
+	}
+	return c.env
+}
+
+// sources returns the layers to load
+func (c *configFlags) sources() []configSource {
+	return configSources(c.configs())
+}
+
+// configSources returns the layers to load: the named files, or the default
+// layers when none are named
+func configSources(files []string) []configSource {
+	if len(files) == 0 {
+		return defaultConfigSources()
+	}
+	return fileSources(files)
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestRun_Dispatch(t *testing.T) {
+	tests := []struct {
+		name     string
+		args     []string
+		code     int
+		expected string
+	}{
+		{name: "help", args: []string{"help"}, code: 0, expected: "Commands:"},
+		{name: "help flag", args: []string{"-h"}, code: 0, expected: "resolve"},
+		{name: "unknown command", args: []string{"frobnicate"}, code: 2, expected: `Unknown command "frobnicate"`},
+		{name: "subcommand", args: []string{"config"}, code: 2, expected: "gopherlol config convert"},
+		{name: "flags run the server", args: []string{"-bogus"}, code: 2, expected: "flag provided but not defined: -bogus"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out, errOut bytes.Buffer
+			if code := run(tt.args, envFunc(nil), &out, &errOut); code != tt.code {
+				t.Errorf("Expected exit code %d, got %d", tt.code, code)
+			}
+			if output := out.String() + errOut.String(); !strings.Contains(output, tt.expected) {
+				t.Errorf("Expected output to contain %q, got %q", tt.expected, output)
+			}
+		})
+	}
+}
+
+func TestPrintUsage_HidesInternalCommands(t *testing.T) {
+	var out bytes.Buffer
+	printUsage(&out)
+	if strings.Contains(out.String(), "__complete") {
+		t.Errorf("Expected __complete to be hidden, got %q", out.String())
+	}
+	if !strings.Contains(out.String(), "completion") {
+		t.Errorf("Expected the completion command to be listed, got %q", out.String())
+	}
+}
+
+func TestAddConfigFlags(t *testing.T) {
+	env := envFunc(map[string]string{"GOPHERLOL_CONFIG": "a.json, https://config.example.com:8443/team.yaml,b.yaml"})
+
+	flags := flag.NewFlagSet("test", flag.ContinueOnError)
+	configFlags := addConfigFlags(flags, env)
+	if err := flags.Parse(nil); err != nil {
+		t.Fatal(err)
+	}
+	if got := configFlags.configs(); !reflect.DeepEqual(got, []string{"a.json", "https://config.example.com:8443/team.yaml", "b.yaml"}) {
+		t.Errorf("Expected configs from the environment, got %v", got)
+	}
+
+	flags = flag.NewFlagSet("test", flag.ContinueOnError)
+	configFlags = addConfigFlags(flags, env)
+	if err := flags.Parse([]string{"-config", "c.toml", "-format", "toml"}); err != nil {
+		t.Fatal(err)
+	}
+	if got := configFlags.configs(); !reflect.DeepEqual(got, []string{"c.toml"}) {
+		t.Errorf("Expected -config to replace the environment, got %v", got)
+	}
+	if configFlags.format != "toml" {
+		t.Errorf("Expected format toml, got %q", configFlags.format)
+	}
+	if sources := configFlags.sources(); len(sources) != 1 || sources[0].Layer != "c.toml" {
+		t.Errorf("Expected one layer for c.toml, got %+v", sources)
+	}
+}
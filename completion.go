@@ -0,0 +1,124 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"strings"
+)
+
+// bashCompletion completes subcommands, and asks gopherlol __complete for
+// the commands of resolve. Bash cannot show descriptions.
+const bashCompletion = `# bash completion for gopherlol
+_gopherlol() {
+    local cur=${COMP_WORDS[COMP_CWORD]}
+    if (( COMP_CWORD == 1 )); then
+        COMPREPLY=($(compgen -W "%s" -- "$cur"))
+        return
+    fi
+    [[ ${COMP_WORDS[1]} == resolve ]] || return
+    local IFS=$'\n'
+    COMPREPLY=($(gopherlol __complete "${COMP_WORDS[@]:2:COMP_CWORD-1}" 2>/dev/null | cut -f1))
+}
+complete -o default -F _gopherlol gopherlol
+`
+
+// zshCompletion is the zsh version of bashCompletion, with descriptions
+const zshCompletion = `#compdef gopherlol
+_gopherlol() {
+    local -a completions
+    local line
+    if (( CURRENT == 2 )); then
+        completions=(%s)
+        _describe 'command' completions
+        return
+    fi
+    [[ ${words[2]} == resolve ]] || return 1
+    for line in "${(@f)$(gopherlol __complete "${(@)words[3,CURRENT]}" 2>/dev/null)}"; do
+        [[ -n $line ]] || continue
+        completions+=("${${line%%$'\t'*}//:/\\:}:${line#*$'\t'}")
+    done
+    _describe 'command' completions
+}
+compdef _gopherlol gopherlol
+`
+
+// fishCompletion is the fish version of bashCompletion, with descriptions
+const fishCompletion = `# fish completion for gopherlol
+function __gopherlol_resolve_words
+    set -l words (commandline -opc)
+    set -e words[1..2]
+    gopherlol __complete $words (commandline -ct) 2>/dev/null
+end
+complete -c gopherlol -f
+%scomplete -c gopherlol -n '__fish_seen_subcommand_from resolve' -a '(__gopherlol_resolve_words)'
+`
+
+// runCompletion prints the completion script for a shell. The scripts list
+// the subcommands and look up commands from the registry when completing,
+// so they never need regenerating after a config change.
+func runCompletion(args []string, getenv func(string) string, out, errOut io.Writer) int {
+	usage := "Usage: gopherlol completion bash|zsh|fish"
+	if len(args) != 1 {
+		_, _ = fmt.Fprintln(errOut, usage)
+		return 2
+	}
+
+	var names, zshEntries []string
+	var fishLines strings.Builder
+	for _, cmd := range subcommands {
+		if cmd.Hidden {
+			continue
+		}
+		names = append(names, cmd.Name)
+		zshEntries = append(zshEntries, fmt.Sprintf("'%s:%s'", cmd.Name, cmd.Summary))
+		fishLines.WriteString(fmt.Sprintf("complete -c gopherlol -n __fish_use_subcommand -a %s -d '%s'\n", cmd.Name, cmd.Summary))
+	}
+
+	switch args[0] {
+	case "bash":
+		_, _ = fmt.Fprintf(out, bashCompletion, strings.Join(names, " "))
+	case "zsh":
+		_, _ = fmt.Fprintf(out, zshCompletion, strings.Join(zshEntries, " "))
+	case "fish":
+		_, _ = fmt.Fprintf(out, fishCompletion, fishLines.String())
+	default:
+		_, _ = fmt.Fprintf(errOut, "Unknown shell %q\n%s\n", args[0], usage)
+		return 2
+	}
+	return 0
+}
+
+// runComplete is the hidden command the completion scripts call with the
+// words typed after "gopherlol resolve", the last one being completed. It
+// prints one candidate for the last word per line, followed by a tab and its
+// description. Flags are parsed as resolve would, so -config is honoured.
+func runComplete(args []string, getenv func(string) string, out, errOut io.Writer) int {
+	flags := flag.NewFlagSet("__complete", flag.ContinueOnError)
+	flags.SetOutput(io.Discard)
+	configFlags := addConfigFlags(flags, getenv)
+	flags.Bool("open", false, "")
+	flags.Bool("json", false, "")
+	if err := flags.Parse(args); err != nil || flags.NArg() == 0 {
+		// Nothing to complete, or the word being typed is a flag value
+		return 0
+	}
+
+	registry, _, err := loadRegistry(configFlags.sources(), configFlags.format, log.New(io.Discard, "", 0), false)
+	if err != nil {
+		return 1
+	}
+
+	words := flags.Args()
+	for _, suggestion := range registry.Suggest(strings.Join(words, " ")) {
+		// Suggest also offers the subcommands of a complete command name,
+		// which belong to the next word
+		completed := strings.Split(suggestion.Completion, " ")
+		if len(completed) != len(words) {
+			continue
+		}
+		_, _ = fmt.Fprintf(out, "%s\t%s\n", completed[len(completed)-1], suggestion.Description)
+	}
+	return 0
+}
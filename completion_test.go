@@ -0,0 +1,60 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestRunComplete(t *testing.T) {
+	path := writeTestConfig(t, resolveTestConfig)
+
+	tests := []struct {
+		name     string
+		words    []string
+		expected string
+	}{
+		{name: "command names", words: []string{"g"}, expected: "g\t\ngh\tGitHub\n"},
+		{name: "aliases", words: []string{"s"}, expected: "stackoverflow\tStack Overflow\nso\tStack Overflow\n"},
+		{name: "subcommands", words: []string{"gh", ""}, expected: "pr\tPull requests\n"},
+		{name: "after resolve flags", words: []string{"-open", "gh", "p"}, expected: "pr\tPull requests\n"},
+		{name: "no subcommands", words: []string{"so", "go", ""}, expected: ""},
+		{name: "flag value", words: []string{"-format"}, expected: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out, errOut bytes.Buffer
+			args := append([]string{"-config", path}, tt.words...)
+			if code := runComplete(args, envFunc(nil), &out, &errOut); code != 0 {
+				t.Fatalf("Expected exit code 0, got %d", code)
+			}
+			if out.String() != tt.expected {
+				t.Errorf("Expected %q, got %q", tt.expected, out.String())
+			}
+		})
+	}
+}
+
+func TestRunCompletion(t *testing.T) {
+	for _, shell := range []string{"bash", "zsh", "fish"} {
+		t.Run(shell, func(t *testing.T) {
+			var out, errOut bytes.Buffer
+			if code := runCompletion([]string{shell}, envFunc(nil), &out, &errOut); code != 0 {
+				t.Fatalf("Expected exit code 0, got %d: %s", code, errOut.String())
+			}
+			script := out.String()
+			if !strings.Contains(script, "gopherlol __complete") || !strings.Contains(script, "resolve") {
+				t.Errorf("Expected the script to complete resolve through __complete, got %s", script)
+			}
+			if strings.Contains(script, "__complete:") || strings.Contains(script, "-a __complete") {
+				t.Errorf("Expected the hidden command not to be offered, got %s", script)
+			}
+		})
+	}
+
+	var out, errOut bytes.Buffer
+	if code := runCompletion([]string{"powershell"}, envFunc(nil), &out, &errOut); code != 2 {
+		t.Errorf("Expected exit code 2 for an unknown shell, got %d", code)
+	}
+}
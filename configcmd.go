@@ -0,0 +1,89 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"github.com/olion500/gopherlol/internal/config"
+	"io"
+	"os"
+)
+
+// runConfig dispatches the config subcommands and returns the exit code
+func runConfig(args []string, getenv func(string) string, out, errOut io.Writer) int {
+	if len(args) == 0 || args[0] != "convert" {
+		_, _ = fmt.Fprintln(errOut, "Usage: gopherlol config convert [-from format] [-to format] <input> <output>")
+		return 2
+	}
+	return runConfigConvert(args[1:], out, errOut)
+}
+
+// runConfigConvert rewrites a config file in another format. Formats are
+// taken from the file extensions unless given explicitly, and an output of
+// "-" writes to out. Comments are lost, so a warning is printed when the
+// input has any.
+func runConfigConvert(args []string, out, errOut io.Writer) int {
+	flags := flag.NewFlagSet("config convert", flag.ContinueOnError)
+	flags.SetOutput(errOut)
+	from := flags.String("from", "", "input format: json, yaml or toml (default: from the extension)")
+	to := flags.String("to", "", "output format: json, yaml or toml (default: from the extension)")
+	flags.Usage = func() {
+		_, _ = fmt.Fprintln(errOut, "Usage: gopherlol config convert [-from format] [-to format] <input> <output>")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if flags.NArg() != 2 {
+		flags.Usage()
+		return 2
+	}
+	input, output := flags.Arg(0), flags.Arg(1)
+
+	inputFormat, err := formatFor(*from, input)
+	if err != nil {
+		_, _ = fmt.Fprintln(errOut, err)
+		return 2
+	}
+	outputFormat, err := formatFor(*to, output)
+	if err != nil {
+		_, _ = fmt.Fprintln(errOut, err)
+		return 2
+	}
+
+	commandConfig, err := config.LoadConfigFormat(input, inputFormat)
+	if err != nil {
+		_, _ = fmt.Fprintf(errOut, "Failed to load %s: %v\n", input, err)
+		return 1
+	}
+
+	if config.SourceHasComments(input, inputFormat) {
+		_, _ = fmt.Fprintf(errOut, "Warning: the comments in %s are not carried over to %s\n", input, output)
+	}
+
+	data, err := config.EncodeConfig(commandConfig, outputFormat)
+	if err != nil {
+		_, _ = fmt.Fprintln(errOut, err)
+		return 1
+	}
+
+	if output == "-" {
+		_, err = out.Write(data)
+	} else {
+		err = os.WriteFile(output, data, 0644)
+	}
+	if err != nil {
+		_, _ = fmt.Fprintf(errOut, "Failed to write %s: %v\n", output, err)
+		return 1
+	}
+
+	return 0
+}
+
+// formatFor returns the explicitly requested format, or the one implied by
+// the file name
+func formatFor(explicit, filename string) (string, error) {
+	if explicit != "" {
+		return config.ParseFormat(explicit)
+	}
+	return config.DetectFormat(filename), nil
+}
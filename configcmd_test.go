@@ -0,0 +1,85 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRunConfigConvert(t *testing.T) {
+	dir := t.TempDir()
+	yamlFile := filepath.Join(dir, "commands.yaml")
+	tomlFile := filepath.Join(dir, "commands.toml")
+
+	var out, errOut bytes.Buffer
+	if code := runConfig([]string{"convert", "commands.json.sample", yamlFile}, envFunc(nil), &out, &errOut); code != 0 {
+		t.Fatalf("Expected exit code 0, got %d: %s", code, errOut.String())
+	}
+	data, err := os.ReadFile(yamlFile)
+	if err != nil || !strings.Contains(string(data), "- name: google") {
+		t.Fatalf("Expected YAML output, got %q (%v)", data, err)
+	}
+
+	if code := runConfig([]string{"convert", yamlFile, tomlFile}, envFunc(nil), &out, &errOut); code != 0 {
+		t.Fatalf("Expected exit code 0, got %d: %s", code, errOut.String())
+	}
+
+	if code := runConfig([]string{"convert", "-to", "json", tomlFile, "-"}, envFunc(nil), &out, &errOut); code != 0 {
+		t.Fatalf("Expected exit code 0, got %d: %s", code, errOut.String())
+	}
+	if !strings.Contains(out.String(), `"name": "google"`) {
+		t.Errorf("Expected JSON on stdout, got %q", out.String())
+	}
+}
+
+func TestRunConfigConvert_WarnsAboutComments(t *testing.T) {
+	dir := t.TempDir()
+	input := filepath.Join(dir, "commands.yaml")
+	if err := os.WriteFile(input, []byte("commands:\n  # owner: platform\n  - name: go\n    url: https://go.dev\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var out, errOut bytes.Buffer
+	if code := runConfig([]string{"convert", input, filepath.Join(dir, "commands.json")}, envFunc(nil), &out, &errOut); code != 0 {
+		t.Fatalf("Expected exit code 0, got %d: %s", code, errOut.String())
+	}
+	if !strings.Contains(errOut.String(), "comments in "+input+" are not carried over") {
+		t.Errorf("Expected a warning about lost comments, got %q", errOut.String())
+	}
+
+	errOut.Reset()
+	if code := runConfig([]string{"convert", "commands.json.sample", filepath.Join(dir, "out.toml")}, envFunc(nil), &out, &errOut); code != 0 {
+		t.Fatalf("Expected exit code 0, got %d: %s", code, errOut.String())
+	}
+	if errOut.Len() != 0 {
+		t.Errorf("Expected no warning for a config without comments, got %q", errOut.String())
+	}
+}
+
+func TestRunConfigConvert_Errors(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		code int
+	}{
+		{"no subcommand", nil, 2},
+		{"unknown subcommand", []string{"merge"}, 2},
+		{"missing output", []string{"convert", "commands.json.sample"}, 2},
+		{"unknown format", []string{"convert", "-to", "xml", "commands.json.sample", "-"}, 2},
+		{"missing input", []string{"convert", "missing.json", "-"}, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out, errOut bytes.Buffer
+			if code := runConfig(tt.args, envFunc(nil), &out, &errOut); code != tt.code {
+				t.Errorf("Expected exit code %d, got %d", tt.code, code)
+			}
+			if errOut.Len() == 0 {
+				t.Error("Expected an error message")
+			}
+		})
+	}
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"github.com/olion500/gopherlol/internal/config"
+	"io"
+	"os"
+)
+
+// runExport writes the configuration the server would load, with every
+// layer, include and fragment merged, as a single file. The output format is
+// taken from -to, or from the output file's extension, and defaults to JSON.
+func runExport(args []string, getenv func(string) string, out, errOut io.Writer) int {
+	flags := flag.NewFlagSet("export", flag.ContinueOnError)
+	flags.SetOutput(errOut)
+	configFlags := addConfigFlags(flags, getenv)
+	to := flags.String("to", "", "output format: json, yaml or toml (default: from the output extension, or json)")
+	output := flags.String("o", "-", `file to write, or "-" for standard output`)
+	flags.Usage = func() {
+		_, _ = fmt.Fprintln(errOut, "Usage: gopherlol export [-config file] [-format format] [-to format] [-o file]")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if flags.NArg() > 0 {
+		flags.Usage()
+		return 2
+	}
+
+	outputFormat := config.FormatJSON
+	if *to != "" || *output != "-" {
+		var err error
+		if outputFormat, err = formatFor(*to, *output); err != nil {
+			_, _ = fmt.Fprintln(errOut, err)
+			return 2
+		}
+	}
+
+	loaded, err := loadLayers(configFlags.sources(), configFlags.format)
+	if err != nil {
+		_, _ = fmt.Fprintf(errOut, "Failed to load command configuration: %v\n", err)
+		return 1
+	}
+	for _, err := range loaded.Stale {
+		_, _ = fmt.Fprintf(errOut, "Warning: %v\n", err)
+	}
+
+	data, err := config.EncodeConfig(loaded.Config, outputFormat)
+	if err != nil {
+		_, _ = fmt.Fprintln(errOut, err)
+		return 1
+	}
+
+	if *output == "-" {
+		_, err = out.Write(data)
+	} else {
+		err = os.WriteFile(*output, data, 0644)
+	}
+	if err != nil {
+		_, _ = fmt.Fprintf(errOut, "Failed to write %s: %v\n", *output, err)
+		return 1
+	}
+
+	return 0
+}
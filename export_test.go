@@ -0,0 +1,59 @@
+package main
+
+import (
+	"bytes"
+	"github.com/olion500/gopherlol/internal/config"
+	"path/filepath"
+	"testing"
+)
+
+func TestRunExport(t *testing.T) {
+	team := writeTestConfig(t, `{"commands": [
+		{"name": "g", "url": "https://google.com/search?q={{.Query}}", "default": true},
+		{"name": "gh", "url": "https://github.com"}
+	]}`)
+	user := writeTestConfig(t, `{"disable": ["gh"], "commands": [{"name": "jira", "url": "https://jira.example.com"}]}`)
+
+	var out, errOut bytes.Buffer
+	if code := runExport([]string{"-config", team, "-config", user, "-to", "yaml"}, envFunc(nil), &out, &errOut); code != 0 {
+		t.Fatalf("Expected exit code 0, got %d: %s", code, errOut.String())
+	}
+
+	exported, err := config.DecodeConfig(out.Bytes(), config.FormatYAML)
+	if err != nil {
+		t.Fatalf("Expected YAML output, got %q: %v", out.String(), err)
+	}
+	if names := commandNames(exported); len(names) != 2 || names[0] != "g" || names[1] != "jira" {
+		t.Errorf("Expected merged commands [g jira], got %v", names)
+	}
+	if len(exported.Disable) != 0 {
+		t.Errorf("Expected no disable list in the merged config, got %v", exported.Disable)
+	}
+}
+
+func TestRunExport_File(t *testing.T) {
+	team := writeTestConfig(t, `{"commands": [{"name": "g", "url": "https://google.com/search?q={{.Query}}"}]}`)
+	output := filepath.Join(t.TempDir(), "export.toml")
+
+	var out, errOut bytes.Buffer
+	if code := runExport([]string{"-config", team, "-o", output}, envFunc(nil), &out, &errOut); code != 0 {
+		t.Fatalf("Expected exit code 0, got %d: %s", code, errOut.String())
+	}
+
+	exported, err := config.LoadConfig(output)
+	if err != nil {
+		t.Fatalf("Expected a TOML file: %v", err)
+	}
+	if len(exported.Commands) != 1 || exported.Commands[0].Name != "g" {
+		t.Errorf("Expected command g, got %+v", exported.Commands)
+	}
+}
+
+// commandNames lists the names of the commands in cfg
+func commandNames(cfg *config.CommandConfig) []string {
+	var names []string
+	for _, cmd := range cfg.Commands {
+		names = append(names, cmd.Name)
+	}
+	return names
+}
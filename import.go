@@ -0,0 +1,124 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"github.com/olion500/gopherlol/internal/config"
+	"io"
+	"io/fs"
+	"os"
+)
+
+// runImport merges the commands of a config file or URL into a config file,
+// the single -config given or else the team config in the working
+// directory. Existing commands are kept unless -replace is set, and every
+// decision is printed. The target is rewritten without its comments.
+func runImport(args []string, getenv func(string) string, out, errOut io.Writer) int {
+	flags := flag.NewFlagSet("import", flag.ContinueOnError)
+	flags.SetOutput(errOut)
+	configFlags := addConfigFlags(flags, getenv)
+	from := flags.String("from", "", "format of the imported file: json, yaml or toml (default: from the extension)")
+	replace := flags.Bool("replace", false, "replace commands that are already defined")
+	dryRun := flags.Bool("dry-run", false, "report what would be imported without writing anything")
+	flags.Usage = func() {
+		_, _ = fmt.Fprintln(errOut, "Usage: gopherlol import [-config file] [-format format] [-from format] [-replace] [-dry-run] <file or URL>")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return 2
+	}
+	source := flags.Arg(0)
+
+	target, err := importTarget(configFlags.configs())
+	if err != nil {
+		_, _ = fmt.Fprintln(errOut, err)
+		return 2
+	}
+	targetFormat, err := formatFor(configFlags.format, target)
+	if err != nil {
+		_, _ = fmt.Fprintln(errOut, err)
+		return 2
+	}
+
+	imported, _, err := loadConfigFile(source, *from)
+	if config.IsStale(err) {
+		_, _ = fmt.Fprintf(errOut, "Warning: %v\n", err)
+	} else if err != nil {
+		_, _ = fmt.Fprintf(errOut, "Failed to load %s: %v\n", source, err)
+		return 1
+	}
+
+	commandConfig, err := config.LoadConfigFormat(target, targetFormat)
+	if errors.Is(err, fs.ErrNotExist) {
+		commandConfig = &config.CommandConfig{}
+	} else if err != nil {
+		_, _ = fmt.Fprintf(errOut, "Failed to load %s: %v\n", target, err)
+		return 1
+	}
+
+	count := 0
+	for _, result := range config.ImportCommands(commandConfig, imported, *replace) {
+		_, _ = fmt.Fprintln(out, result)
+		if result.Action != config.ImportSkipped {
+			count++
+		}
+	}
+
+	failed := false
+	for _, issue := range config.Lint(commandConfig) {
+		if issue.Severity == config.SeverityError {
+			_, _ = fmt.Fprintf(errOut, "%s: %s\n", issue.Code, issue.Message)
+			failed = true
+		}
+	}
+	if failed {
+		_, _ = fmt.Fprintf(errOut, "Not writing %s: the merged config has errors\n", target)
+		return 1
+	}
+
+	if count == 0 {
+		_, _ = fmt.Fprintf(out, "Nothing to import into %s\n", target)
+		return 0
+	}
+	if *dryRun {
+		_, _ = fmt.Fprintf(out, "Would import %d of %d commands into %s\n", count, len(imported.Commands), target)
+		return 0
+	}
+
+	data, err := config.EncodeConfig(commandConfig, targetFormat)
+	if err != nil {
+		_, _ = fmt.Fprintln(errOut, err)
+		return 1
+	}
+	if err := os.WriteFile(target, data, 0644); err != nil {
+		_, _ = fmt.Fprintf(errOut, "Failed to write %s: %v\n", target, err)
+		return 1
+	}
+
+	_, _ = fmt.Fprintf(out, "Imported %d of %d commands into %s\n", count, len(imported.Commands), target)
+	return 0
+}
+
+// importTarget picks the config file to import into: the one named, or the
+// team config in the working directory, created as commands.json when there
+// is none
+func importTarget(configs []string) (string, error) {
+	switch {
+	case len(configs) > 1:
+		return "", errors.New("import writes to a single config file, name one with -config")
+	case len(configs) == 1 && config.IsRemote(configs[0]):
+		return "", fmt.Errorf("cannot import into %s: remote configs are read-only", configs[0])
+	case len(configs) == 1:
+		return configs[0], nil
+	}
+
+	if path := (configSource{Files: configDirFiles(".")}).path(); path != "" {
+		return path, nil
+	}
+	return "commands.json", nil
+}
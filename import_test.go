@@ -0,0 +1,94 @@
+package main
+
+import (
+	"bytes"
+	"github.com/olion500/gopherlol/internal/config"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRunImport(t *testing.T) {
+	target := writeTestConfig(t, `{"commands": [
+		{"name": "g", "aliases": ["google"], "url": "https://google.com/search?q={{.Query}}", "default": true}
+	]}`)
+	source := filepath.Join(t.TempDir(), "shared.yaml")
+	shared := `commands:
+  - name: jira
+    url: https://jira.example.com
+  - name: g
+    url: https://duckduckgo.com/?q={{.Query}}
+`
+	if err := os.WriteFile(source, []byte(shared), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	var out, errOut bytes.Buffer
+	if code := runImport([]string{"-config", target, source}, envFunc(nil), &out, &errOut); code != 0 {
+		t.Fatalf("Expected exit code 0, got %d: %s", code, errOut.String())
+	}
+	for _, expected := range []string{"added jira", "skipped g: already defined", "Imported 1 of 2 commands"} {
+		if !strings.Contains(out.String(), expected) {
+			t.Errorf("Expected output to contain %q, got %q", expected, out.String())
+		}
+	}
+
+	imported, err := config.LoadConfig(target)
+	if err != nil {
+		t.Fatalf("Failed to load the target: %v", err)
+	}
+	if names := commandNames(imported); len(names) != 2 || names[1] != "jira" {
+		t.Errorf("Expected [g jira], got %v", names)
+	}
+	if imported.Commands[0].URL != "https://google.com/search?q={{.Query}}" {
+		t.Errorf("Expected g to be kept, got %q", imported.Commands[0].URL)
+	}
+
+	out.Reset()
+	if code := runImport([]string{"-config", target, "-replace", source}, envFunc(nil), &out, &errOut); code != 0 {
+		t.Fatalf("Expected exit code 0, got %d: %s", code, errOut.String())
+	}
+	imported, _ = config.LoadConfig(target)
+	if imported.Commands[0].URL != "https://duckduckgo.com/?q={{.Query}}" {
+		t.Errorf("Expected g to be replaced, got %q", imported.Commands[0].URL)
+	}
+}
+
+func TestRunImport_DryRun(t *testing.T) {
+	target := filepath.Join(t.TempDir(), "commands.json")
+	source := writeTestConfig(t, `{"commands": [{"name": "jira", "url": "https://jira.example.com"}]}`)
+
+	var out, errOut bytes.Buffer
+	if code := runImport([]string{"-config", target, "-dry-run", source}, envFunc(nil), &out, &errOut); code != 0 {
+		t.Fatalf("Expected exit code 0, got %d: %s", code, errOut.String())
+	}
+	if !strings.Contains(out.String(), "Would import 1 of 1 commands") {
+		t.Errorf("Expected a dry run summary, got %q", out.String())
+	}
+	if _, err := os.Stat(target); !os.IsNotExist(err) {
+		t.Errorf("Expected the dry run not to create %s", target)
+	}
+}
+
+func TestRunImport_Errors(t *testing.T) {
+	source := writeTestConfig(t, `{"commands": [{"name": "jira", "url": "https://jira.example.com"}]}`)
+
+	tests := []struct {
+		name string
+		args []string
+	}{
+		{name: "no source", args: []string{"-config", "a.json"}},
+		{name: "several targets", args: []string{"-config", "a.json", "-config", "b.json", source}},
+		{name: "remote target", args: []string{"-config", "https://example.com/commands.json", source}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out, errOut bytes.Buffer
+			if code := runImport(tt.args, envFunc(nil), &out, &errOut); code != 2 {
+				t.Errorf("Expected exit code 2, got %d", code)
+			}
+		})
+	}
+}
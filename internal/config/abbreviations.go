@@ -0,0 +1,111 @@
+package config
+
+import "strings"
+
+// AbbreviationConfig controls typing a unique prefix of a command or
+// subcommand name instead of the whole name, e.g. "stacko" for
+// "stackoverflow"
+type AbbreviationConfig struct {
+	// Mode is "on" or "off" (the default)
+	Mode string `json:"mode,omitempty" yaml:"mode,omitempty" toml:"mode,omitempty"`
+	// MinLength is the shortest prefix that is expanded
+	MinLength int `json:"minLength,omitempty" yaml:"minLength,omitempty" toml:"minLength,omitempty"`
+}
+
+// Abbreviation modes
+const (
+	AbbreviationsOn  = "on"
+	AbbreviationsOff = "off"
+)
+
+// defaultMinAbbreviation is used when AbbreviationConfig.MinLength is unset
+const defaultMinAbbreviation = 3
+
+// CommandMatch is a command an abbreviated name may stand for
+type CommandMatch struct {
+	Command *Command
+	Key     string // name or alias the abbreviation expands to
+}
+
+// SubcommandMatch is a subcommand an abbreviated name may stand for
+type SubcommandMatch struct {
+	Subcommand *Subcommand
+	Key        string // name or alias the abbreviation expands to
+}
+
+// FindCommandsByPrefix returns the commands whose name or alias starts
+// with prefix, for expanding an abbreviated command name. Each command
+// appears once, under its name if that matches, with name matches first.
+// Nothing is returned when abbreviations are off or prefix is too short;
+// exact names and aliases are looked up with FindCommand.
+func (r *CommandRegistry) FindCommandsByPrefix(prefix string) []CommandMatch {
+	prefix = strings.ToLower(prefix)
+	if !r.abbreviates(prefix) {
+		return nil
+	}
+
+	keys, commands := matchPrefix(prefix, r.commands, r.aliases)
+	matches := make([]CommandMatch, len(commands))
+	for i, cmd := range commands {
+		matches[i] = CommandMatch{Command: cmd, Key: keys[i]}
+	}
+	return matches
+}
+
+// MatchSubcommandPath walks the subcommand tree of a command like
+// FindSubcommandPath, also following unique prefixes of subcommand names.
+// When the walk stops at a token that abbreviates more than one
+// subcommand, those subcommands are returned too.
+func (r *CommandRegistry) MatchSubcommandPath(cmdName string, tokens []string) ([]*Subcommand, []SubcommandMatch) {
+	var path []*Subcommand
+
+	subMap := r.commandSubcommands(cmdName)
+	for _, token := range tokens {
+		token = strings.ToLower(token)
+		sub, exists := subMap.get(token)
+		if !exists && r.abbreviates(token) {
+			keys, subs := matchPrefix(token, subMap)
+			if len(subs) > 1 {
+				matches := make([]SubcommandMatch, len(subs))
+				for i, sub := range subs {
+					matches[i] = SubcommandMatch{Subcommand: sub, Key: keys[i]}
+				}
+				return path, matches
+			}
+			if len(subs) == 1 {
+				sub, exists = subs[0], true
+			}
+		}
+		if !exists {
+			break
+		}
+		path = append(path, sub)
+		subMap = r.children[sub]
+	}
+
+	return path, nil
+}
+
+// abbreviates reports whether prefix may be expanded to a longer name
+func (r *CommandRegistry) abbreviates(prefix string) bool {
+	return r.abbreviations.Mode == AbbreviationsOn && len([]rune(prefix)) >= r.abbreviations.MinLength
+}
+
+// matchPrefix collects the values of the keys starting with prefix, each
+// value once under the first such key, searching the tries in order
+func matchPrefix[T comparable](prefix string, tries ...*trie[T]) ([]string, []T) {
+	var keys []string
+	var values []T
+	seen := make(map[T]bool)
+	for _, t := range tries {
+		t.walk(prefix, func(key string, value T) {
+			if seen[value] {
+				return
+			}
+			seen[value] = true
+			keys = append(keys, key)
+			values = append(values, value)
+		})
+	}
+	return keys, values
+}
@@ -0,0 +1,114 @@
+package config
+
+import (
+	"reflect"
+	"testing"
+)
+
+func newAbbreviationRegistry(settings *AbbreviationConfig) *CommandRegistry {
+	return NewCommandRegistry(&CommandConfig{
+		Abbreviations: settings,
+		Commands: []Command{
+			{Name: "stackoverflow", Aliases: []string{"so", "stack"}, URL: "https://stackoverflow.com/search?q={{.Query}}"},
+			{Name: "github", Aliases: []string{"gh"}, URL: "https://github.com/search?q={{.Query}}", Subcommands: []Subcommand{
+				{Name: "issues", URL: "https://github.com/issues?q={{.Query}}"},
+				{Name: "pr", Aliases: []string{"pulls"}, URL: "https://github.com/pulls?q={{.Query}}"},
+				{Name: "profile", URL: "https://github.com/settings/profile"},
+				{Name: "projects", URL: "https://github.com/projects?q={{.Query}}"},
+				{Name: "logs", URL: "https://github.com/logs", Subcommands: []Subcommand{
+					{Name: "production", URL: "https://github.com/logs/production"},
+				}},
+			}},
+			{Name: "gitlab", URL: "https://gitlab.com/search?search={{.Query}}"},
+			{Name: "jenkins", Aliases: []string{"ci"}, URL: "https://ci.example.com"},
+		},
+	})
+}
+
+func TestFindCommandsByPrefix(t *testing.T) {
+	registry := newAbbreviationRegistry(&AbbreviationConfig{Mode: AbbreviationsOn})
+
+	tests := []struct {
+		prefix   string
+		expected []string // keys of the matches
+	}{
+		{prefix: "stacko", expected: []string{"stackoverflow"}},
+		// A command matching by name and alias appears once, under its name
+		{prefix: "STA", expected: []string{"stackoverflow"}},
+		{prefix: "git", expected: []string{"github", "gitlab"}},
+		{prefix: "jen", expected: []string{"jenkins"}},
+		// Prefixes shorter than the minimum length are never expanded
+		{prefix: "gi"},
+		{prefix: "unknown"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.prefix, func(t *testing.T) {
+			var keys []string
+			for _, match := range registry.FindCommandsByPrefix(tt.prefix) {
+				keys = append(keys, match.Key)
+			}
+			if !reflect.DeepEqual(keys, tt.expected) {
+				t.Errorf("FindCommandsByPrefix(%q) = %v, expected %v", tt.prefix, keys, tt.expected)
+			}
+		})
+	}
+}
+
+func TestFindCommandsByPrefix_Settings(t *testing.T) {
+	for _, settings := range []*AbbreviationConfig{nil, {MinLength: 3}, {Mode: AbbreviationsOff}} {
+		registry := newAbbreviationRegistry(settings)
+		if matches := registry.FindCommandsByPrefix("stacko"); matches != nil {
+			t.Errorf("Expected no matches unless abbreviations are on, got %+v for %+v", matches, settings)
+		}
+	}
+
+	registry := newAbbreviationRegistry(&AbbreviationConfig{Mode: AbbreviationsOn, MinLength: 5})
+	if matches := registry.FindCommandsByPrefix("jenk"); matches != nil {
+		t.Errorf("Expected no matches below the minimum length, got %+v", matches)
+	}
+	if matches := registry.FindCommandsByPrefix("jenki"); len(matches) != 1 {
+		t.Errorf("Expected jenkins, got %+v", matches)
+	}
+}
+
+func TestMatchSubcommandPath(t *testing.T) {
+	registry := newAbbreviationRegistry(&AbbreviationConfig{Mode: AbbreviationsOn})
+
+	tests := []struct {
+		name      string
+		tokens    []string
+		path      []string
+		ambiguous []string
+	}{
+		{name: "exact", tokens: []string{"issues", "bug"}, path: []string{"issues"}},
+		{name: "prefix", tokens: []string{"iss", "bug"}, path: []string{"issues"}},
+		{name: "alias prefix", tokens: []string{"pul"}, path: []string{"pr"}},
+		{name: "nested prefix", tokens: []string{"log", "prod"}, path: []string{"logs", "production"}},
+		{name: "too short", tokens: []string{"is"}},
+		{name: "no match", tokens: []string{"react", "hooks"}},
+		{name: "ambiguous", tokens: []string{"pro"}, ambiguous: []string{"profile", "projects"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path, ambiguous := registry.MatchSubcommandPath("gh", tt.tokens)
+
+			var names []string
+			for _, sub := range path {
+				names = append(names, sub.Name)
+			}
+			if !reflect.DeepEqual(names, tt.path) {
+				t.Errorf("Expected path %v, got %v", tt.path, names)
+			}
+
+			var candidates []string
+			for _, match := range ambiguous {
+				candidates = append(candidates, match.Subcommand.Name)
+			}
+			if !reflect.DeepEqual(candidates, tt.ambiguous) {
+				t.Errorf("Expected ambiguous %v, got %v", tt.ambiguous, candidates)
+			}
+		})
+	}
+}
@@ -2,84 +2,267 @@ package config
 
 import (
 	"bytes"
-	"encoding/json"
 	"fmt"
-	"os"
 	"strings"
 	"text/template"
 )
 
-// CommandConfig represents the JSON configuration structure
+// CommandConfig represents the configuration file structure, whatever its
+// format
 type CommandConfig struct {
-	Commands []Command `json:"commands"`
+	Commands   []Command         `json:"commands" yaml:"commands" toml:"commands"`
+	DidYouMean *DidYouMeanConfig `json:"didYouMean,omitempty" yaml:"didYouMean,omitempty" toml:"didYouMean,omitempty"`
+	Fallback   *FallbackConfig   `json:"fallback,omitempty" yaml:"fallback,omitempty" toml:"fallback,omitempty"`
+	// Abbreviations controls expanding unique prefixes of names, see FindCommandsByPrefix
+	Abbreviations *AbbreviationConfig `json:"abbreviations,omitempty" yaml:"abbreviations,omitempty" toml:"abbreviations,omitempty"`
+	Navigation    *NavigationConfig   `json:"navigation,omitempty" yaml:"navigation,omitempty" toml:"navigation,omitempty"`
+	// Patterns match queries that do not start with a command, see MatchPattern
+	Patterns []Pattern `json:"patterns,omitempty" yaml:"patterns,omitempty" toml:"patterns,omitempty"`
+	// Disable lists commands or aliases from earlier layers to remove, see MergeLayers
+	Disable []string `json:"disable,omitempty" yaml:"disable,omitempty" toml:"disable,omitempty"`
+	// Include lists more config files to merge in, see LoadConfigFiles
+	Include []string `json:"include,omitempty" yaml:"include,omitempty" toml:"include,omitempty"`
 }
 
+// DidYouMeanConfig controls what happens when an unknown command closely
+// resembles a known one
+type DidYouMeanConfig struct {
+	// Mode is "off" (the default), "suggest" to show a page of candidates,
+	// or "autocorrect" to run the only candidate directly
+	Mode string `json:"mode" yaml:"mode" toml:"mode"`
+	// MaxDistance is the largest edit distance considered a match
+	MaxDistance int `json:"maxDistance,omitempty" yaml:"maxDistance,omitempty" toml:"maxDistance,omitempty"`
+}
+
+// Did-you-mean modes
+const (
+	DidYouMeanOff         = "off"
+	DidYouMeanSuggest     = "suggest"
+	DidYouMeanAutocorrect = "autocorrect"
+)
+
+// defaultMaxDistance is used when DidYouMeanConfig.MaxDistance is unset
+const defaultMaxDistance = 2
+
 // Command represents a single command configuration
 type Command struct {
-	Name          string       `json:"name"`
-	Aliases       []string     `json:"aliases"`
-	Description   string       `json:"description"`
-	URL           string       `json:"url"`
-	RequiresQuery bool         `json:"requiresQuery"`
-	Default       bool         `json:"default,omitempty"`
-	Subcommands   []Subcommand `json:"subcommands,omitempty"`
+	Name          string       `json:"name" yaml:"name" toml:"name"`
+	Aliases       []string     `json:"aliases,omitempty" yaml:"aliases,omitempty" toml:"aliases,omitempty"`
+	Description   string       `json:"description,omitempty" yaml:"description,omitempty" toml:"description,omitempty"`
+	URL           string       `json:"url" yaml:"url" toml:"url"`
+	RequiresQuery bool         `json:"requiresQuery,omitempty" yaml:"requiresQuery,omitempty" toml:"requiresQuery,omitempty"`
+	EmptyURL      string       `json:"emptyURL,omitempty" yaml:"emptyURL,omitempty" toml:"emptyURL,omitempty"`
+	Default       bool         `json:"default,omitempty" yaml:"default,omitempty" toml:"default,omitempty"`
+	Encoding      string       `json:"encoding,omitempty" yaml:"encoding,omitempty" toml:"encoding,omitempty"`
+	Params        []Param      `json:"params,omitempty" yaml:"params,omitempty" toml:"params,omitempty"`
+	Flags         []Flag       `json:"flags,omitempty" yaml:"flags,omitempty" toml:"flags,omitempty"`
+	Subcommands   []Subcommand `json:"subcommands,omitempty" yaml:"subcommands,omitempty" toml:"subcommands,omitempty"`
+	Examples      []Example    `json:"examples,omitempty" yaml:"examples,omitempty" toml:"examples,omitempty"`
+
+	// Source names the config layer the command came from, see MergeLayers
+	Source string `json:"-" yaml:"-" toml:"-"`
+	// Origin is the file and line the command was defined at, see LoadConfigFiles
+	Origin Origin `json:"-" yaml:"-" toml:"-"`
+
+	urlTemplate      *template.Template
+	emptyURLTemplate *template.Template
 }
 
-// Subcommand represents a subcommand configuration
+// Subcommand represents a subcommand configuration.
+// Subcommands may have subcommands of their own, forming a tree.
 type Subcommand struct {
-	Name        string   `json:"name"`
-	Aliases     []string `json:"aliases"`
-	Description string   `json:"description"`
-	URL         string   `json:"url"`
+	Name        string       `json:"name" yaml:"name" toml:"name"`
+	Aliases     []string     `json:"aliases,omitempty" yaml:"aliases,omitempty" toml:"aliases,omitempty"`
+	Description string       `json:"description,omitempty" yaml:"description,omitempty" toml:"description,omitempty"`
+	URL         string       `json:"url" yaml:"url" toml:"url"`
+	Encoding    string       `json:"encoding,omitempty" yaml:"encoding,omitempty" toml:"encoding,omitempty"`
+	Params      []Param      `json:"params,omitempty" yaml:"params,omitempty" toml:"params,omitempty"`
+	Flags       []Flag       `json:"flags,omitempty" yaml:"flags,omitempty" toml:"flags,omitempty"`
+	Subcommands []Subcommand `json:"subcommands,omitempty" yaml:"subcommands,omitempty" toml:"subcommands,omitempty"`
+	Examples    []Example    `json:"examples,omitempty" yaml:"examples,omitempty" toml:"examples,omitempty"`
+
+	urlTemplate *template.Template
+}
+
+// Example is a full query and the URL it must resolve to, checked by the
+// test command and shown on the help page
+type Example struct {
+	Query string `json:"query" yaml:"query" toml:"query"`
+	URL   string `json:"url" yaml:"url" toml:"url"`
+}
+
+// Param declares a named positional argument, bound to query tokens in order
+type Param struct {
+	Name        string `json:"name" yaml:"name" toml:"name"`
+	Description string `json:"description,omitempty" yaml:"description,omitempty" toml:"description,omitempty"`
+	Optional    bool   `json:"optional,omitempty" yaml:"optional,omitempty" toml:"optional,omitempty"`
 }
 
 // TemplateData holds data for URL template processing
 type TemplateData struct {
-	Query string
+	Query string            // query with whitespace collapsed, escaped with the command's encoding
+	Raw   string            // query exactly as typed
+	Args  Args              // escaped words by position and declared params by name, see Tokenize
+	Flags map[string]string // escaped values of the declared flags by name
+}
+
+// Args exposes query tokens to templates both by position ({{index .Args 0}})
+// and by declared parameter name ({{.Args.owner}}), escaped with the
+// command's encoding
+type Args map[interface{}]string
+
+// ArgumentError reports a query that is missing required arguments
+type ArgumentError struct {
+	Params  []Param
+	Missing []string
+}
+
+// Error describes the missing arguments
+func (e *ArgumentError) Error() string {
+	names := make([]string, len(e.Missing))
+	for i, name := range e.Missing {
+		names[i] = "<" + name + ">"
+	}
+	if len(names) == 1 {
+		return "missing required argument " + names[0]
+	}
+	return "missing required arguments " + strings.Join(names, " ")
+}
+
+// Usage returns the expected argument list, e.g. "<owner> <repo> [<branch>]"
+func (e *ArgumentError) Usage() string {
+	return ParamUsage(e.Params)
 }
 
 // CommandRegistry manages command lookup and execution
 type CommandRegistry struct {
-	commands       map[string]*Command
-	aliases        map[string]*Command
-	subcommands    map[string]map[string]*Subcommand
+	// Names, aliases and each level of subcommands are tries keyed by
+	// lowercase name, so that prefixes can be listed and expanded
+	commands       *trie[*Command]
+	aliases        *trie[*Command]
+	subcommands    map[*Command]*trie[*Subcommand]
+	children       map[*Subcommand]*trie[*Subcommand]
 	defaultCommand *Command
+	didYouMean     DidYouMeanConfig
+	navigation     NavigationConfig
+	abbreviations  AbbreviationConfig
+
+	fallbackChain      []FallbackStep
+	emptyQueryURL      string
+	emptyQueryTemplate *template.Template
+
+	patterns []Pattern
+
+	// errs collects problems found while compiling the configuration
+	errs []error
 }
 
-// LoadConfig loads command configuration from a JSON file
+// LoadConfig loads command configuration from a file or http(s) URL,
+// choosing the format from its extension
 func LoadConfig(filename string) (*CommandConfig, error) {
-	data, err := os.ReadFile(filename)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read config file: %w", err)
+	return LoadConfigFormat(filename, DetectFormat(filename))
+}
+
+// LoadConfigFormat loads command configuration from a file or http(s) URL in
+// the given format. When a URL cannot be fetched but a cached copy exists,
+// the cached config is returned along with a *StaleError.
+func LoadConfigFormat(filename, format string) (*CommandConfig, error) {
+	data, err := readSource(filename, format)
+	if err != nil && !IsStale(err) {
+		return nil, err
 	}
 
-	var config CommandConfig
-	if err := json.Unmarshal(data, &config); err != nil {
-		return nil, fmt.Errorf("failed to parse config JSON: %w", err)
+	config, decodeErr := DecodeConfig(data, format)
+	if decodeErr != nil {
+		return nil, decodeErr
 	}
+	return config, err
+}
 
-	return &config, nil
+// SourceHasComments reports whether the config file or URL at source
+// contains comments in the given format
+func SourceHasComments(source, format string) bool {
+	data, err := readSource(source, format)
+	if err != nil && !IsStale(err) {
+		return false
+	}
+	return HasComments(data, format)
 }
 
-// NewCommandRegistry creates a new command registry from configuration
+// NewCommandRegistry creates a new command registry from configuration.
+// URL templates are compiled here; templates that fail to compile are
+// reported by NewValidatedCommandRegistry and fail when used.
 func NewCommandRegistry(config *CommandConfig) *CommandRegistry {
 	registry := &CommandRegistry{
-		commands:       make(map[string]*Command),
-		aliases:        make(map[string]*Command),
-		subcommands:    make(map[string]map[string]*Subcommand),
+		commands:       newTrie[*Command](),
+		aliases:        newTrie[*Command](),
+		subcommands:    make(map[*Command]*trie[*Subcommand]),
+		children:       make(map[*Subcommand]*trie[*Subcommand]),
 		defaultCommand: nil,
+		didYouMean:     DidYouMeanConfig{Mode: DidYouMeanOff, MaxDistance: defaultMaxDistance},
+		navigation:     NavigationConfig{Mode: NavigationOff, Scheme: defaultNavigationScheme, LocalScheme: defaultNavigationLocalScheme},
+		abbreviations:  AbbreviationConfig{Mode: AbbreviationsOff, MinLength: defaultMinAbbreviation},
 	}
 
+	if config.DidYouMean != nil {
+		if config.DidYouMean.Mode != "" {
+			registry.didYouMean.Mode = config.DidYouMean.Mode
+		}
+		if config.DidYouMean.MaxDistance > 0 {
+			registry.didYouMean.MaxDistance = config.DidYouMean.MaxDistance
+		}
+	}
+
+	if config.Abbreviations != nil {
+		if config.Abbreviations.Mode != "" {
+			registry.abbreviations.Mode = config.Abbreviations.Mode
+		}
+		if config.Abbreviations.MinLength > 0 {
+			registry.abbreviations.MinLength = config.Abbreviations.MinLength
+		}
+	}
+
+	if config.Navigation != nil {
+		// Listing suffixes turns navigation on
+		switch {
+		case config.Navigation.Mode != "":
+			registry.navigation.Mode = config.Navigation.Mode
+		case len(config.Navigation.Suffixes) > 0:
+			registry.navigation.Mode = NavigationOn
+		}
+		if config.Navigation.Scheme != "" {
+			registry.navigation.Scheme = config.Navigation.Scheme
+		}
+		if config.Navigation.LocalScheme != "" {
+			registry.navigation.LocalScheme = config.Navigation.LocalScheme
+		}
+		registry.navigation.Suffixes = append([]string(nil), config.Navigation.Suffixes...)
+	}
+
+	// Set up the fallback chain for queries no command handles
+	if config.Fallback != nil {
+		registry.fallbackChain = append([]FallbackStep(nil), config.Fallback.Chain...)
+		registry.emptyQueryURL = config.Fallback.EmptyURL
+	}
+	if len(registry.fallbackChain) == 0 {
+		registry.fallbackChain = defaultFallbackChain(registry.didYouMean)
+	}
+	registry.compileFallbackPatterns()
+	registry.compileFallbackTemplates()
+
+	registry.patterns = append([]Pattern(nil), config.Patterns...)
+	registry.compilePatterns()
+
 	// Register commands and aliases
 	for i := range config.Commands {
 		cmd := &config.Commands[i]
 
 		// Register main command name
-		registry.commands[strings.ToLower(cmd.Name)] = cmd
+		registry.commands.insert(strings.ToLower(cmd.Name), cmd)
 
 		// Register aliases
 		for _, alias := range cmd.Aliases {
-			registry.aliases[strings.ToLower(alias)] = cmd
+			registry.aliases.insert(strings.ToLower(alias), cmd)
 		}
 
 		// Set default command if specified
@@ -87,39 +270,47 @@ func NewCommandRegistry(config *CommandConfig) *CommandRegistry {
 			registry.defaultCommand = cmd
 		}
 
+		// Parse URL templates once up front
+		registry.compileCommandTemplates(cmd)
+
 		// Register subcommands if any
 		if len(cmd.Subcommands) > 0 {
-			subMap := make(map[string]*Subcommand)
-			for j := range cmd.Subcommands {
-				sub := &cmd.Subcommands[j]
-				subMap[strings.ToLower(sub.Name)] = sub
-
-				// Register subcommand aliases
-				for _, alias := range sub.Aliases {
-					subMap[strings.ToLower(alias)] = sub
-				}
-			}
-			registry.subcommands[strings.ToLower(cmd.Name)] = subMap
-
-			// Also register subcommands under aliases
-			for _, alias := range cmd.Aliases {
-				registry.subcommands[strings.ToLower(alias)] = subMap
-			}
+			registry.subcommands[cmd] = registry.registerSubcommands(cmd.Subcommands)
 		}
 	}
 
 	return registry
 }
 
+// registerSubcommands builds the lookup table for one level of subcommands,
+// recursing into nested subcommands
+func (r *CommandRegistry) registerSubcommands(subs []Subcommand) *trie[*Subcommand] {
+	subMap := newTrie[*Subcommand]()
+	for i := range subs {
+		sub := &subs[i]
+		subMap.insert(strings.ToLower(sub.Name), sub)
+
+		// Register subcommand aliases
+		for _, alias := range sub.Aliases {
+			subMap.insert(strings.ToLower(alias), sub)
+		}
+
+		if len(sub.Subcommands) > 0 {
+			r.children[sub] = r.registerSubcommands(sub.Subcommands)
+		}
+	}
+	return subMap
+}
+
 // FindCommand looks up a command by name or alias
 func (r *CommandRegistry) FindCommand(name string) *Command {
 	name = strings.ToLower(name)
 
-	if cmd, exists := r.commands[name]; exists {
+	if cmd, exists := r.commands.get(name); exists {
 		return cmd
 	}
 
-	if cmd, exists := r.aliases[name]; exists {
+	if cmd, exists := r.aliases.get(name); exists {
 		return cmd
 	}
 
@@ -128,16 +319,41 @@ func (r *CommandRegistry) FindCommand(name string) *Command {
 
 // FindSubcommand looks up a subcommand for a given command
 func (r *CommandRegistry) FindSubcommand(cmdName, subName string) *Subcommand {
-	cmdName = strings.ToLower(cmdName)
-	subName = strings.ToLower(subName)
+	cmd := r.FindCommand(cmdName)
+	if cmd == nil {
+		return nil
+	}
 
-	if subMap, exists := r.subcommands[cmdName]; exists {
-		if sub, exists := subMap[subName]; exists {
-			return sub
+	sub, _ := r.subcommands[cmd].get(strings.ToLower(subName))
+	return sub
+}
+
+// FindSubcommandPath walks the subcommand tree of a command as far as the
+// tokens match and returns the matched subcommands, outermost first
+func (r *CommandRegistry) FindSubcommandPath(cmdName string, tokens []string) []*Subcommand {
+	var path []*Subcommand
+
+	subMap := r.commandSubcommands(cmdName)
+	for _, token := range tokens {
+		sub, exists := subMap.get(strings.ToLower(token))
+		if !exists {
+			break
 		}
+		path = append(path, sub)
+		subMap = r.children[sub]
 	}
 
-	return nil
+	return path
+}
+
+// commandSubcommands returns the first level of subcommands of a command,
+// nil when it has none
+func (r *CommandRegistry) commandSubcommands(cmdName string) *trie[*Subcommand] {
+	cmd := r.FindCommand(cmdName)
+	if cmd == nil {
+		return nil
+	}
+	return r.subcommands[cmd]
 }
 
 // GetDefaultCommand returns the configured default command
@@ -145,15 +361,130 @@ func (r *CommandRegistry) GetDefaultCommand() *Command {
 	return r.defaultCommand
 }
 
+// GetDidYouMean returns the did-you-mean settings with defaults applied
+func (r *CommandRegistry) GetDidYouMean() DidYouMeanConfig {
+	return r.didYouMean
+}
+
+// Expand builds the command's target URL for the raw query
+func (c *Command) Expand(raw string) (string, error) {
+	return expand(c.urlTemplate, c.URL, c.Params, c.Flags, c.Encoding, raw)
+}
+
+// ExpandEmpty builds the URL for a command that requires a query but was
+// invoked without one
+func (c *Command) ExpandEmpty() (string, error) {
+	return expand(c.emptyURLTemplate, c.EmptyURL, nil, c.Flags, c.Encoding, "")
+}
+
+// Expand builds the subcommand's target URL for the raw query
+func (s *Subcommand) Expand(raw string) (string, error) {
+	return expand(s.urlTemplate, s.URL, s.Params, s.Flags, s.Encoding, raw)
+}
+
+// expand binds the raw query and executes the URL template. Templates are
+// compiled by NewCommandRegistry; source is only parsed here for commands
+// that never went through a registry.
+func expand(tmpl *template.Template, source string, params []Param, flags []Flag, encoding, raw string) (string, error) {
+	data, err := NewTemplateData(raw, params, flags, encoding)
+	if err != nil {
+		return "", err
+	}
+	if tmpl == nil {
+		return RenderURL(source, data)
+	}
+	return executeURLTemplate(tmpl, data)
+}
+
+// NewTemplateData splits the raw query into arguments with Tokenize, takes
+// out the declared flags and binds the declared params to the remaining
+// arguments. It fails if a flag has an invalid value or a required param
+// has no matching token. Flags are left out of the query, including Raw,
+// and everything is escaped with the given encoding.
+func NewTemplateData(raw string, params []Param, flags []Flag, encoding string) (TemplateData, error) {
+	all := Tokenize(raw)
+	flagValues, tokens, err := parseFlags(all, flags)
+	if err != nil {
+		return TemplateData{}, err
+	}
+
+	query, err := Encode(encoding, JoinTokens(tokens))
+	if err != nil {
+		return TemplateData{}, err
+	}
+
+	data := TemplateData{
+		Query: query,
+		Raw:   withoutFlags(raw, all, tokens),
+		Args:  make(Args),
+		Flags: make(map[string]string, len(flagValues)),
+	}
+
+	for name, value := range flagValues {
+		// The encoding is known to be valid at this point
+		data.Flags[name], _ = Encode(encoding, value)
+	}
+	for i, token := range tokens {
+		// The encoding is known to be valid at this point
+		data.Args[i], _ = Encode(encoding, token.Value)
+	}
+
+	var missing []string
+	for i, param := range params {
+		switch {
+		case i < len(tokens):
+			data.Args[param.Name] = data.Args[i]
+		case param.Optional:
+			data.Args[param.Name] = ""
+		default:
+			missing = append(missing, param.Name)
+		}
+	}
+	if len(missing) > 0 {
+		return data, &ArgumentError{Params: params, Missing: missing}
+	}
+
+	return data, nil
+}
+
+// ParamUsage formats params as an argument list, marking optional ones
+func ParamUsage(params []Param) string {
+	usage := make([]string, len(params))
+	for i, param := range params {
+		usage[i] = "<" + param.Name + ">"
+		if param.Optional {
+			usage[i] = "[" + usage[i] + "]"
+		}
+	}
+	return strings.Join(usage, " ")
+}
+
 // ExecuteURL processes the URL template with the given query
 func ExecuteURL(urlTemplate, query string) (string, error) {
-	tmpl, err := template.New("url").Parse(urlTemplate)
+	return RenderURL(urlTemplate, TemplateData{Query: query})
+}
+
+// RenderURL processes the URL template with the given template data
+func RenderURL(urlTemplate string, data TemplateData) (string, error) {
+	tmpl, err := parseURLTemplate(urlTemplate)
+	if err != nil {
+		return "", err
+	}
+	return executeURLTemplate(tmpl, data)
+}
+
+// parseURLTemplate compiles a URL template
+func parseURLTemplate(urlTemplate string) (*template.Template, error) {
+	tmpl, err := template.New("url").Option("missingkey=zero").Funcs(templateFuncs).Parse(urlTemplate)
 	if err != nil {
-		return "", fmt.Errorf("failed to parse URL template: %w", err)
+		return nil, fmt.Errorf("failed to parse URL template: %w", err)
 	}
+	return tmpl, nil
+}
 
+// executeURLTemplate runs a compiled URL template with the given data
+func executeURLTemplate(tmpl *template.Template, data TemplateData) (string, error) {
 	var buf bytes.Buffer
-	data := TemplateData{Query: query}
 
 	if err := tmpl.Execute(&buf, data); err != nil {
 		return "", fmt.Errorf("failed to execute URL template: %w", err)
@@ -162,17 +493,12 @@ func ExecuteURL(urlTemplate, query string) (string, error) {
 	return buf.String(), nil
 }
 
-// ListCommands returns all available commands for help display
+// ListCommands returns all available commands for help display, sorted by
+// name. Each command carries its full subcommand tree.
 func (r *CommandRegistry) ListCommands() []Command {
 	var commands []Command
-	seen := make(map[string]bool)
-
-	for _, cmd := range r.commands {
-		if !seen[cmd.Name] {
-			commands = append(commands, *cmd)
-			seen[cmd.Name] = true
-		}
-	}
-
+	r.commands.walk("", func(_ string, cmd *Command) {
+		commands = append(commands, *cmd)
+	})
 	return commands
 }
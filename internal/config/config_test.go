@@ -1,6 +1,7 @@
 package config
 
 import (
+	"errors"
 	"os"
 	"path/filepath"
 	"testing"
@@ -189,3 +190,154 @@ func TestListCommands(t *testing.T) {
 		t.Error("Not all commands found in list")
 	}
 }
+
+func TestNewTemplateData(t *testing.T) {
+	params := []Param{{Name: "owner"}, {Name: "repo"}, {Name: "branch", Optional: true}}
+
+	data, err := NewTemplateData("olion500 gopherlol", params, nil, "")
+	if err != nil {
+		t.Fatalf("NewTemplateData failed: %v", err)
+	}
+
+	if data.Raw != "olion500 gopherlol" {
+		t.Errorf("Expected raw query to be kept, got %q", data.Raw)
+	}
+	if data.Query != "olion500+gopherlol" {
+		t.Errorf("Expected escaped query, got %q", data.Query)
+	}
+
+	testCases := []struct {
+		template string
+		expected string
+	}{
+		{"https://github.com/{{.Args.owner}}/{{.Args.repo}}/pulls", "https://github.com/olion500/gopherlol/pulls"},
+		{"https://github.com/{{index .Args 0}}/{{index .Args 1}}", "https://github.com/olion500/gopherlol"},
+		{"https://github.com/{{.Args.owner}}/tree/{{.Args.branch}}", "https://github.com/olion500/tree/"},
+	}
+
+	for _, tc := range testCases {
+		result, err := RenderURL(tc.template, data)
+		if err != nil {
+			t.Errorf("RenderURL failed for template %q: %v", tc.template, err)
+			continue
+		}
+		if result != tc.expected {
+			t.Errorf("RenderURL(%q) = %q, expected %q", tc.template, result, tc.expected)
+		}
+	}
+}
+
+func TestNewTemplateData_EscapesArgs(t *testing.T) {
+	data, err := NewTemplateData("a&b c", nil, nil, "")
+	if err != nil {
+		t.Fatalf("NewTemplateData failed: %v", err)
+	}
+
+	if data.Args[0] != "a%26b" || data.Args[1] != "c" {
+		t.Errorf("Expected escaped positional args, got %v", data.Args)
+	}
+	if data.Raw != "a&b c" {
+		t.Errorf("Expected raw query to stay unescaped, got %q", data.Raw)
+	}
+}
+
+func TestNewTemplateData_Quotes(t *testing.T) {
+	params := []Param{{Name: "project"}, {Name: "summary"}}
+
+	data, err := NewTemplateData("  web  “login page broken” ", params, nil, "")
+	if err != nil {
+		t.Fatalf("NewTemplateData failed: %v", err)
+	}
+
+	if data.Args["project"] != "web" || data.Args["summary"] != "login+page+broken" {
+		t.Errorf("Expected a quoted phrase to be one argument, got %v", data.Args)
+	}
+	if data.Query != "web+%22login+page+broken%22" {
+		t.Errorf("Expected the query with collapsed whitespace and straight quotes, got %q", data.Query)
+	}
+	if data.Raw != "  web  “login page broken” " {
+		t.Errorf("Expected raw query to be kept as typed, got %q", data.Raw)
+	}
+}
+
+func TestNewTemplateData_MissingArgs(t *testing.T) {
+	params := []Param{{Name: "owner"}, {Name: "repo"}, {Name: "branch", Optional: true}}
+
+	_, err := NewTemplateData("olion500", params, nil, "")
+
+	var argErr *ArgumentError
+	if !errors.As(err, &argErr) {
+		t.Fatalf("Expected ArgumentError, got %v", err)
+	}
+
+	if len(argErr.Missing) != 1 || argErr.Missing[0] != "repo" {
+		t.Errorf("Expected missing 'repo', got %v", argErr.Missing)
+	}
+	if err.Error() != "missing required argument <repo>" {
+		t.Errorf("Unexpected error message %q", err.Error())
+	}
+	if argErr.Usage() != "<owner> <repo> [<branch>]" {
+		t.Errorf("Unexpected usage %q", argErr.Usage())
+	}
+}
+
+func TestFindSubcommandPath(t *testing.T) {
+	config := &CommandConfig{
+		Commands: []Command{
+			{
+				Name:    "datadog",
+				Aliases: []string{"dd"},
+				URL:     "https://app.datadoghq.com",
+				Subcommands: []Subcommand{
+					{
+						Name:    "logs",
+						Aliases: []string{"log"},
+						URL:     "https://app.datadoghq.com/logs?query={{.Query}}",
+						Subcommands: []Subcommand{
+							{
+								Name: "prod",
+								URL:  "https://app.datadoghq.com/logs?query=env:prod+{{.Query}}",
+								Subcommands: []Subcommand{
+									{Name: "errors", URL: "https://app.datadoghq.com/logs?query=env:prod+status:error+{{.Query}}"},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	registry := NewCommandRegistry(config)
+
+	testCases := []struct {
+		cmdName  string
+		tokens   []string
+		expected []string
+	}{
+		{"dd", []string{"logs", "prod", "errors", "timeout"}, []string{"logs", "prod", "errors"}},
+		{"datadog", []string{"LOG", "prod", "timeout"}, []string{"logs", "prod"}},
+		{"dd", []string{"logs", "staging"}, []string{"logs"}},
+		{"dd", []string{"prod"}, nil},
+		{"dd", nil, nil},
+		{"unknown", []string{"logs"}, nil},
+	}
+
+	for _, tc := range testCases {
+		path := registry.FindSubcommandPath(tc.cmdName, tc.tokens)
+		if len(path) != len(tc.expected) {
+			t.Errorf("FindSubcommandPath(%q, %v) matched %d subcommands, expected %v", tc.cmdName, tc.tokens, len(path), tc.expected)
+			continue
+		}
+		for i, sub := range path {
+			if sub.Name != tc.expected[i] {
+				t.Errorf("FindSubcommandPath(%q, %v)[%d] = %q, expected %q", tc.cmdName, tc.tokens, i, sub.Name, tc.expected[i])
+			}
+		}
+	}
+
+	commands := registry.ListCommands()
+	if len(commands) != 1 || len(commands[0].Subcommands[0].Subcommands[0].Subcommands) != 1 {
+		t.Error("Expected ListCommands to carry the full subcommand tree")
+	}
+}
@@ -0,0 +1,60 @@
+package config
+
+import (
+	"encoding/base64"
+	"fmt"
+	"net/url"
+	"strings"
+	"text/template"
+)
+
+// Query encodings a command can declare for {{.Query}} and {{.Args}}
+const (
+	EncodingQuery       = "query"        // url.QueryEscape, spaces become "+"
+	EncodingPath        = "path"         // path escaping that keeps "/" separators
+	EncodingPathSegment = "path-segment" // path escaping that also escapes "/"
+	EncodingRaw         = "raw"          // no escaping at all
+	EncodingBase64      = "base64"       // URL-safe base64
+)
+
+// templateFuncs lets template authors pick an encoding per placeholder,
+// e.g. {{pathEscape .Raw}}
+var templateFuncs = template.FuncMap{
+	"queryEscape":   url.QueryEscape,
+	"pathEscape":    escapePath,
+	"segmentEscape": url.PathEscape,
+	"base64":        encodeBase64,
+}
+
+// Encode escapes s for use in a URL according to encoding.
+// An empty encoding means EncodingQuery.
+func Encode(encoding, s string) (string, error) {
+	switch encoding {
+	case "", EncodingQuery:
+		return url.QueryEscape(s), nil
+	case EncodingPath:
+		return escapePath(s), nil
+	case EncodingPathSegment:
+		return url.PathEscape(s), nil
+	case EncodingRaw:
+		return s, nil
+	case EncodingBase64:
+		return encodeBase64(s), nil
+	default:
+		return "", fmt.Errorf("unknown encoding %q", encoding)
+	}
+}
+
+// escapePath escapes each "/"-separated segment of s, keeping the slashes
+func escapePath(s string) string {
+	segments := strings.Split(s, "/")
+	for i, segment := range segments {
+		segments[i] = url.PathEscape(segment)
+	}
+	return strings.Join(segments, "/")
+}
+
+// encodeBase64 encodes s using the URL-safe base64 alphabet
+func encodeBase64(s string) string {
+	return base64.URLEncoding.EncodeToString([]byte(s))
+}
@@ -0,0 +1,80 @@
+package config
+
+import "testing"
+
+func TestEncode(t *testing.T) {
+	testCases := []struct {
+		encoding string
+		input    string
+		expected string
+	}{
+		{"", "hello world/x", "hello+world%2Fx"},
+		{EncodingQuery, "a&b c", "a%26b+c"},
+		{EncodingPath, "golang/go tools", "golang/go%20tools"},
+		{EncodingPathSegment, "golang/go tools", "golang%2Fgo%20tools"},
+		{EncodingRaw, "a b/c?d", "a b/c?d"},
+		{EncodingBase64, "service:web status:error", "c2VydmljZTp3ZWIgc3RhdHVzOmVycm9y"},
+	}
+
+	for _, tc := range testCases {
+		result, err := Encode(tc.encoding, tc.input)
+		if err != nil {
+			t.Errorf("Encode(%q, %q) failed: %v", tc.encoding, tc.input, err)
+			continue
+		}
+		if result != tc.expected {
+			t.Errorf("Encode(%q, %q) = %q, expected %q", tc.encoding, tc.input, result, tc.expected)
+		}
+	}
+}
+
+func TestEncode_Unknown(t *testing.T) {
+	if _, err := Encode("rot13", "x"); err == nil {
+		t.Error("Expected error for unknown encoding, got nil")
+	}
+}
+
+func TestExpand_Encoding(t *testing.T) {
+	testCases := []struct {
+		cmd      Command
+		query    string
+		expected string
+	}{
+		{
+			cmd:      Command{URL: "https://pkg.go.dev/{{.Query}}", Encoding: EncodingPath},
+			query:    "golang.org/x/tools",
+			expected: "https://pkg.go.dev/golang.org/x/tools",
+		},
+		{
+			cmd:      Command{URL: "https://github.com/{{index .Args 0}}/issues", Encoding: EncodingPath},
+			query:    "olion500/gopherlol",
+			expected: "https://github.com/olion500/gopherlol/issues",
+		},
+		{
+			cmd:      Command{URL: "https://example.com/search?q={{.Query}}&path={{pathEscape .Raw}}"},
+			query:    "a b",
+			expected: "https://example.com/search?q=a+b&path=a%20b",
+		},
+		{
+			cmd:      Command{URL: "https://example.com/{{segmentEscape .Raw}}?q={{queryEscape .Raw}}&b={{base64 .Raw}}"},
+			query:    "x/y",
+			expected: "https://example.com/x%2Fy?q=x%2Fy&b=eC95",
+		},
+	}
+
+	for _, tc := range testCases {
+		result, err := tc.cmd.Expand(tc.query)
+		if err != nil {
+			t.Errorf("Expand(%q) with template %q failed: %v", tc.query, tc.cmd.URL, err)
+			continue
+		}
+		if result != tc.expected {
+			t.Errorf("Expand(%q) with template %q = %q, expected %q", tc.query, tc.cmd.URL, result, tc.expected)
+		}
+	}
+
+	sub := Subcommand{URL: "https://example.com/{{.Query}}", Encoding: EncodingRaw}
+	if result, err := sub.Expand("a b"); err != nil || result != "https://example.com/a b" {
+		t.Errorf("Expected subcommand encoding to apply, got %q (%v)", result, err)
+	}
+}
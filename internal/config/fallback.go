@@ -0,0 +1,121 @@
+package config
+
+import (
+	"strconv"
+	"text/template"
+)
+
+// GoogleSearchURL is the last resort when no fallback chain is configured
+const GoogleSearchURL = "https://www.google.com/?q={{.Query}}"
+
+// FallbackConfig decides what happens to queries that no command handles
+type FallbackConfig struct {
+	// Chain lists the strategies to try in order; the first that applies wins
+	Chain []FallbackStep `json:"chain,omitempty" yaml:"chain,omitempty" toml:"chain,omitempty"`
+	// EmptyURL is where an empty query goes; when unset the chain handles it
+	EmptyURL string `json:"emptyURL,omitempty" yaml:"emptyURL,omitempty" toml:"emptyURL,omitempty"`
+}
+
+// FallbackStep is one strategy in the fallback chain
+type FallbackStep struct {
+	// Type is one of the Fallback* constants
+	Type string `json:"type" yaml:"type" toml:"type"`
+	// Name labels the step in analytics; defaults to "<type>-fallback"
+	Name string `json:"name,omitempty" yaml:"name,omitempty" toml:"name,omitempty"`
+	// Pattern is the regular expression a "pattern" step matches the whole
+	// query against, with named groups available to URL as in Pattern.Match
+	Pattern string `json:"pattern,omitempty" yaml:"pattern,omitempty" toml:"pattern,omitempty"`
+	// URL is the template used by "pattern" and "url" steps
+	URL string `json:"url,omitempty" yaml:"url,omitempty" toml:"url,omitempty"`
+	// Encoding applies to the query in URL, see Encode
+	Encoding string `json:"encoding,omitempty" yaml:"encoding,omitempty" toml:"encoding,omitempty"`
+	// Message is shown by "error" steps
+	Message string `json:"message,omitempty" yaml:"message,omitempty" toml:"message,omitempty"`
+
+	urlTemplate *template.Template
+	pattern     *Pattern
+}
+
+// Fallback step types
+const (
+	FallbackDefault = "default" // hand the query to the default command, if any
+	FallbackFuzzy   = "fuzzy"   // offer or run commands similar to the unknown one
+	FallbackPattern = "pattern" // redirect to URL when the whole query matches Pattern
+	FallbackURL     = "url"     // always redirect to URL
+	FallbackError   = "error"   // show an error page with Message
+)
+
+// Label returns the name identifying the step in analytics
+func (s FallbackStep) Label() string {
+	if s.Name != "" {
+		return s.Name
+	}
+	return s.Type + "-fallback"
+}
+
+// Expand builds the step's target URL for the raw query
+func (s FallbackStep) Expand(raw string) (string, error) {
+	return expand(s.urlTemplate, s.URL, nil, nil, s.Encoding, raw)
+}
+
+// MatchPattern returns the pattern of a "pattern" step and the values of
+// its named groups when it matches the whole query, or nil. Only steps of
+// a registry's chain are compiled; others never match.
+func (s FallbackStep) MatchPattern(query string) (*Pattern, map[string]string) {
+	if s.pattern == nil {
+		return nil, nil
+	}
+	groups, matched := s.pattern.match(query)
+	if !matched {
+		return nil, nil
+	}
+	return s.pattern, groups
+}
+
+// asPattern returns the Pattern a "pattern" step stands for
+func (s FallbackStep) asPattern() Pattern {
+	return Pattern{Name: s.Label(), Match: s.Pattern, URL: s.URL, Encoding: s.Encoding}
+}
+
+// defaultFallbackChain reproduces the historical behavior: the default
+// command if one is configured, Google otherwise
+func defaultFallbackChain(didYouMean DidYouMeanConfig) []FallbackStep {
+	var chain []FallbackStep
+	if didYouMean.Mode != DidYouMeanOff {
+		chain = append(chain, FallbackStep{Type: FallbackFuzzy})
+	}
+	return append(chain,
+		FallbackStep{Type: FallbackDefault},
+		FallbackStep{Type: FallbackURL, Name: "google-fallback", URL: GoogleSearchURL},
+	)
+}
+
+// compileFallbackPatterns compiles the patterns of all "pattern" steps
+func (r *CommandRegistry) compileFallbackPatterns() {
+	for i := range r.fallbackChain {
+		step := &r.fallbackChain[i]
+		if step.Type != FallbackPattern {
+			continue
+		}
+		field := "fallback.chain[" + strconv.Itoa(i) + "]"
+		pattern := step.asPattern()
+		r.compilePattern(&pattern, field+".pattern", field+".url")
+		step.pattern = &pattern
+	}
+}
+
+// GetFallbackChain returns the configured fallback chain, or the implicit
+// one when the configuration does not define a chain
+func (r *CommandRegistry) GetFallbackChain() []FallbackStep {
+	return r.fallbackChain
+}
+
+// GetEmptyQueryURL returns where an empty query goes, if configured
+func (r *CommandRegistry) GetEmptyQueryURL() string {
+	return r.emptyQueryURL
+}
+
+// ExpandEmptyQueryURL builds the URL an empty query goes to
+func (r *CommandRegistry) ExpandEmptyQueryURL() (string, error) {
+	return expand(r.emptyQueryTemplate, r.emptyQueryURL, nil, nil, "", "")
+}
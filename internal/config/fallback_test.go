@@ -0,0 +1,67 @@
+package config
+
+import "testing"
+
+func TestGetFallbackChain_Default(t *testing.T) {
+	registry := NewCommandRegistry(&CommandConfig{})
+
+	chain := registry.GetFallbackChain()
+	if len(chain) != 2 || chain[0].Type != FallbackDefault || chain[1].URL != GoogleSearchURL {
+		t.Errorf("Expected default command then Google, got %+v", chain)
+	}
+	if chain[1].Label() != "google-fallback" {
+		t.Errorf("Expected Google step to be labelled 'google-fallback', got %q", chain[1].Label())
+	}
+
+	registry = NewCommandRegistry(&CommandConfig{DidYouMean: &DidYouMeanConfig{Mode: DidYouMeanSuggest}})
+	if chain := registry.GetFallbackChain(); len(chain) != 3 || chain[0].Type != FallbackFuzzy {
+		t.Errorf("Expected fuzzy step first when did-you-mean is enabled, got %+v", chain)
+	}
+}
+
+func TestGetFallbackChain_Configured(t *testing.T) {
+	config := &CommandConfig{
+		Fallback: &FallbackConfig{
+			Chain:    []FallbackStep{{Type: FallbackURL, URL: "https://duckduckgo.com/?q={{.Query}}"}},
+			EmptyURL: "https://example.com/",
+		},
+	}
+	registry := NewCommandRegistry(config)
+
+	chain := registry.GetFallbackChain()
+	if len(chain) != 1 || chain[0].Label() != "url-fallback" {
+		t.Errorf("Expected configured chain, got %+v", chain)
+	}
+	if registry.GetEmptyQueryURL() != "https://example.com/" {
+		t.Errorf("Expected empty query URL, got %q", registry.GetEmptyQueryURL())
+	}
+}
+
+func TestFallbackStep_MatchPattern(t *testing.T) {
+	registry := NewCommandRegistry(&CommandConfig{
+		Fallback: &FallbackConfig{Chain: []FallbackStep{
+			{Type: FallbackPattern, Pattern: `INC(?P<number>\d+)`, URL: "https://example.com/{{.Args.number}}"},
+			{Type: FallbackPattern, Pattern: `^(INC`},
+		}},
+	})
+	chain := registry.GetFallbackChain()
+	valid, invalid := chain[0], chain[1]
+
+	pattern, groups := valid.MatchPattern("INC0012345")
+	if pattern == nil || pattern.Label() != "pattern-fallback" || groups["number"] != "0012345" {
+		t.Errorf("Expected pattern to match with groups, got %+v %v", pattern, groups)
+	}
+	if target, err := pattern.Expand("INC0012345", groups); err != nil || target != "https://example.com/0012345" {
+		t.Errorf("Expected expanded URL, got %q (%v)", target, err)
+	}
+	// Like patterns, the expression must match the whole query
+	if pattern, _ := valid.MatchPattern("see INC1"); pattern != nil {
+		t.Errorf("Expected pattern not to match part of the query, got %+v", pattern)
+	}
+	if pattern, _ := invalid.MatchPattern("INC1"); pattern != nil {
+		t.Errorf("Expected invalid pattern never to match, got %+v", pattern)
+	}
+	if pattern, _ := (FallbackStep{Type: FallbackPattern, Pattern: "INC1"}).MatchPattern("INC1"); pattern != nil {
+		t.Error("Expected a step outside a registry not to match")
+	}
+}
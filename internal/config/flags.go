@@ -0,0 +1,265 @@
+package config
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Flag declares an option that can appear anywhere after the command in a
+// query, as "-name value" or "-name=value". Bool flags take no value unless
+// one is attached with "=". A "--" word ends the flags.
+type Flag struct {
+	Name        string `json:"name" yaml:"name" toml:"name"`
+	Description string `json:"description,omitempty" yaml:"description,omitempty" toml:"description,omitempty"`
+	// Type is one of the Flag* constants; defaults to FlagString
+	Type string `json:"type,omitempty" yaml:"type,omitempty" toml:"type,omitempty"`
+	// Default is the value when the query does not set the flag
+	Default string `json:"default,omitempty" yaml:"default,omitempty" toml:"default,omitempty"`
+	// Values lists the allowed values; any value of the type when empty
+	Values []string `json:"values,omitempty" yaml:"values,omitempty" toml:"values,omitempty"`
+}
+
+// Flag types
+const (
+	FlagString   = "string"
+	FlagBool     = "bool"
+	FlagInt      = "int"
+	FlagDuration = "duration" // Go duration such as "90m" or "1h30m"
+)
+
+// FlagError reports a flag with an invalid value or no value at all
+type FlagError struct {
+	Flag    Flag
+	Value   string
+	Missing bool
+}
+
+// Error describes the problem with the flag's value
+func (e *FlagError) Error() string {
+	if e.Missing {
+		return fmt.Sprintf("flag -%s needs a value", e.Flag.Name)
+	}
+	if len(e.Flag.Values) > 0 {
+		return fmt.Sprintf("invalid value %q for flag -%s, must be one of %s", e.Value, e.Flag.Name, strings.Join(e.Flag.Values, ", "))
+	}
+	return fmt.Sprintf("invalid value %q for flag -%s, must be %s", e.Value, e.Flag.Name, e.Flag.kind())
+}
+
+// Options returns the values the flag accepts, or nil when it accepts any
+// value of its type
+func (f Flag) Options() []string {
+	if len(f.Values) > 0 {
+		return f.Values
+	}
+	if f.Type == FlagBool {
+		return []string{"true", "false"}
+	}
+	return nil
+}
+
+// Usage formats the flag for usage lines, e.g. "[-env prod|staging]"
+func (f Flag) Usage() string {
+	switch {
+	case f.Type == FlagBool:
+		return "[-" + f.Name + "]"
+	case len(f.Values) > 0:
+		return "[-" + f.Name + " " + strings.Join(f.Values, "|") + "]"
+	default:
+		return "[-" + f.Name + " <" + f.typeName() + ">]"
+	}
+}
+
+// FlagUsage formats flags as a usage list
+func FlagUsage(flags []Flag) string {
+	usage := make([]string, len(flags))
+	for i, flag := range flags {
+		usage[i] = flag.Usage()
+	}
+	return strings.Join(usage, " ")
+}
+
+// Parse checks value against the flag's type and allowed values and
+// returns it in canonical form
+func (f Flag) Parse(value string) (string, error) {
+	switch f.Type {
+	case "", FlagString:
+	case FlagBool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return "", &FlagError{Flag: f, Value: value}
+		}
+		value = strconv.FormatBool(b)
+	case FlagInt:
+		if _, err := strconv.Atoi(value); err != nil {
+			return "", &FlagError{Flag: f, Value: value}
+		}
+	case FlagDuration:
+		if _, err := time.ParseDuration(value); err != nil {
+			return "", &FlagError{Flag: f, Value: value}
+		}
+	default:
+		return "", fmt.Errorf("flag -%s has unknown type %q", f.Name, f.Type)
+	}
+
+	if len(f.Values) == 0 {
+		return value, nil
+	}
+	for _, allowed := range f.Values {
+		if strings.EqualFold(allowed, value) {
+			return allowed, nil
+		}
+	}
+	return "", &FlagError{Flag: f, Value: value}
+}
+
+// defaultValue returns the value of a flag the query does not set
+func (f Flag) defaultValue() string {
+	if f.Default == "" && f.Type == FlagBool {
+		return "false"
+	}
+	if value, err := f.Parse(f.Default); err == nil && f.Default != "" {
+		return value
+	}
+	return f.Default
+}
+
+// typeName returns the flag's type with the default applied
+func (f Flag) typeName() string {
+	if f.Type == "" {
+		return FlagString
+	}
+	return f.Type
+}
+
+// kind describes the values of the flag's type for error messages
+func (f Flag) kind() string {
+	switch f.Type {
+	case FlagBool:
+		return "true or false"
+	case FlagInt:
+		return "an integer"
+	case FlagDuration:
+		return "a duration such as 15m or 1h"
+	default:
+		return "a " + f.typeName()
+	}
+}
+
+// flagDeclarationError is a flag declaration that lintFlags rejects
+type flagDeclarationError struct {
+	Issue
+}
+
+// Error names the flag's command and the problem
+func (e *flagDeclarationError) Error() string {
+	location := "flags"
+	if e.Subcommand != "" {
+		location = fmt.Sprintf("subcommand %q: %s", e.Subcommand, location)
+	}
+	return fmt.Sprintf("command %q: %s: %s", e.Command, location, e.Message)
+}
+
+// checkFlags records the problems with the flags of a command or
+// subcommand, which would otherwise only show when a query uses them
+func (r *CommandRegistry) checkFlags(cmdName, subPath string, flags []Flag) {
+	for _, issue := range lintFlags(cmdName, subPath, flags) {
+		r.errs = append(r.errs, &flagDeclarationError{issue})
+	}
+}
+
+// parseFlags takes the declared flags out of the words of a query. It
+// returns the value of every declared flag, defaults filled in, and the
+// words that are not flags.
+func parseFlags(tokens []Token, flags []Flag) (map[string]string, []Token, error) {
+	values := make(map[string]string, len(flags))
+	for _, flag := range flags {
+		values[flag.Name] = flag.defaultValue()
+	}
+	if len(flags) == 0 {
+		return values, tokens, nil
+	}
+
+	var rest []Token
+	for i := 0; i < len(tokens); i++ {
+		token := tokens[i]
+		if token.Raw == "--" {
+			rest = append(rest, tokens[i+1:]...)
+			break
+		}
+
+		flag, value, hasValue := lookupFlag(token, flags)
+		if flag == nil {
+			rest = append(rest, token)
+			continue
+		}
+		if !hasValue {
+			if flag.Type == FlagBool {
+				value = "true"
+			} else if i+1 < len(tokens) {
+				i++
+				value = tokens[i].Value
+			} else {
+				return values, rest, &FlagError{Flag: *flag, Missing: true}
+			}
+		}
+
+		parsed, err := flag.Parse(value)
+		if err != nil {
+			return values, rest, err
+		}
+		values[flag.Name] = parsed
+	}
+
+	return values, rest, nil
+}
+
+// lookupFlag returns the declared flag a word sets, if any, and the value
+// attached to it with "="
+func lookupFlag(token Token, flags []Flag) (*Flag, string, bool) {
+	// Quoted words are never flags
+	if !strings.HasPrefix(token.Raw, "-") {
+		return nil, "", false
+	}
+	name, value, hasValue := strings.Cut(strings.TrimPrefix(token.Value[1:], "-"), "=")
+	for i := range flags {
+		if name != "" && strings.EqualFold(flags[i].Name, name) {
+			return &flags[i], value, hasValue
+		}
+	}
+	return nil, "", false
+}
+
+// withoutFlags rebuilds the raw query from the words that are not flags,
+// keeping the spacing between words that were next to each other
+func withoutFlags(raw string, tokens, rest []Token) string {
+	if len(rest) == len(tokens) {
+		return raw
+	}
+
+	var b strings.Builder
+	end := -1
+	for i, token := range rest {
+		if i > 0 {
+			if adjacent(tokens, rest[i-1], token) {
+				b.WriteString(raw[end:token.Start])
+			} else {
+				b.WriteString(" ")
+			}
+		}
+		b.WriteString(token.Raw)
+		end = token.Start + len(token.Raw)
+	}
+	return b.String()
+}
+
+// adjacent reports whether no word of tokens lies between a and b
+func adjacent(tokens []Token, a, b Token) bool {
+	for _, token := range tokens {
+		if token.Start > a.Start && token.Start < b.Start {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,126 @@
+package config
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+// logFlags are the flags of a log search command used by the tests below
+var logFlags = []Flag{
+	// Defaults are matched against Values like typed values, so this is "prod"
+	{Name: "env", Values: []string{"prod", "staging", "dev"}, Default: "Prod"},
+	{Name: "since", Type: FlagDuration, Default: "15m"},
+	{Name: "limit", Type: FlagInt},
+	{Name: "live", Type: FlagBool},
+}
+
+func TestNewTemplateData_Flags(t *testing.T) {
+	tests := []struct {
+		raw   string
+		flags map[string]string
+		query string
+		rawOf string // expected Raw
+	}{
+		{
+			raw:   "-env staging -since 1h timeout",
+			flags: map[string]string{"env": "staging", "since": "1h", "limit": "", "live": "false"},
+			query: "timeout",
+			rawOf: "timeout",
+		},
+		{
+			raw:   "connection  reset -env=DEV --live by peer",
+			flags: map[string]string{"env": "dev", "since": "15m", "limit": "", "live": "true"},
+			query: "connection+reset+by+peer",
+			rawOf: "connection  reset by peer",
+		},
+		{
+			raw:   `-live=false "-limit" 5 -- -env test`,
+			flags: map[string]string{"env": "prod", "since": "15m", "limit": "", "live": "false"},
+			query: "%22-limit%22+5+-env+test",
+			rawOf: `"-limit" 5 -env test`,
+		},
+		{
+			// Unknown flags and lone dashes are ordinary words
+			raw:   "-verbose - x -limit 20",
+			flags: map[string]string{"env": "prod", "since": "15m", "limit": "20", "live": "false"},
+			query: "-verbose+-+x",
+			rawOf: "-verbose - x",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.raw, func(t *testing.T) {
+			data, err := NewTemplateData(tt.raw, nil, logFlags, "")
+			if err != nil {
+				t.Fatalf("NewTemplateData failed: %v", err)
+			}
+			if !reflect.DeepEqual(data.Flags, tt.flags) {
+				t.Errorf("Expected flags %v, got %v", tt.flags, data.Flags)
+			}
+			if data.Query != tt.query {
+				t.Errorf("Expected query %q, got %q", tt.query, data.Query)
+			}
+			if data.Raw != tt.rawOf {
+				t.Errorf("Expected raw %q, got %q", tt.rawOf, data.Raw)
+			}
+		})
+	}
+}
+
+func TestNewTemplateData_FlagsAndParams(t *testing.T) {
+	params := []Param{{Name: "service"}}
+	data, err := NewTemplateData("-env dev checkout errors", params, logFlags, "")
+	if err != nil {
+		t.Fatalf("NewTemplateData failed: %v", err)
+	}
+
+	url, err := RenderURL("https://logs.example.com/{{.Flags.env}}/{{.Args.service}}?q={{.Query}}&from={{.Flags.since}}", data)
+	if err != nil {
+		t.Fatalf("RenderURL failed: %v", err)
+	}
+	if url != "https://logs.example.com/dev/checkout?q=checkout+errors&from=15m" {
+		t.Errorf("Unexpected URL %q", url)
+	}
+}
+
+func TestNewTemplateData_FlagErrors(t *testing.T) {
+	tests := []struct {
+		raw     string
+		message string
+		options []string
+	}{
+		{raw: "-env qa timeout", message: `invalid value "qa" for flag -env, must be one of prod, staging, dev`, options: []string{"prod", "staging", "dev"}},
+		{raw: "-since soon", message: `invalid value "soon" for flag -since, must be a duration such as 15m or 1h`},
+		{raw: "-limit ten", message: `invalid value "ten" for flag -limit, must be an integer`},
+		{raw: "-live=maybe", message: `invalid value "maybe" for flag -live, must be true or false`, options: []string{"true", "false"}},
+		{raw: "timeout -env", message: "flag -env needs a value", options: []string{"prod", "staging", "dev"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.raw, func(t *testing.T) {
+			_, err := NewTemplateData(tt.raw, nil, logFlags, "")
+
+			var flagErr *FlagError
+			if !errors.As(err, &flagErr) {
+				t.Fatalf("Expected FlagError, got %v", err)
+			}
+			if err.Error() != tt.message {
+				t.Errorf("Expected %q, got %q", tt.message, err.Error())
+			}
+			if !reflect.DeepEqual(flagErr.Flag.Options(), tt.options) {
+				t.Errorf("Expected options %v, got %v", tt.options, flagErr.Flag.Options())
+			}
+		})
+	}
+}
+
+func TestFlagUsage(t *testing.T) {
+	expected := "[-env prod|staging|dev] [-since <duration>] [-limit <int>] [-live]"
+	if usage := FlagUsage(logFlags); usage != expected {
+		t.Errorf("Expected %q, got %q", expected, usage)
+	}
+	if usage := (Flag{Name: "q"}).Usage(); usage != "[-q <string>]" {
+		t.Errorf("Expected string type by default, got %q", usage)
+	}
+}
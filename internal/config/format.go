@@ -0,0 +1,244 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+	"strings"
+)
+
+// Config file formats. JSON files may contain // and /* */ comments and
+// trailing commas, so .jsonc files are read as JSON.
+const (
+	FormatJSON = "json"
+	FormatYAML = "yaml"
+	FormatTOML = "toml"
+)
+
+// formatExtensions maps file extensions to the format they are read as
+var formatExtensions = map[string]string{
+	".json":  FormatJSON,
+	".jsonc": FormatJSON,
+	".yaml":  FormatYAML,
+	".yml":   FormatYAML,
+	".toml":  FormatTOML,
+}
+
+// DetectFormat infers a config format from the extension of a file name or
+// URL path. Unknown extensions are treated as JSON.
+func DetectFormat(filename string) string {
+	if format, ok := formatExtensions[strings.ToLower(sourceExt(filename))]; ok {
+		return format
+	}
+	return FormatJSON
+}
+
+// ParseFormat normalizes a format named by the user, accepting any of the
+// known extensions with or without the leading dot
+func ParseFormat(name string) (string, error) {
+	name = strings.ToLower(name)
+	if !strings.HasPrefix(name, ".") {
+		name = "." + name
+	}
+	if format, ok := formatExtensions[name]; ok {
+		return format, nil
+	}
+	return "", fmt.Errorf("unknown config format %q (want json, yaml or toml)", strings.TrimPrefix(name, "."))
+}
+
+// DecodeConfig parses a configuration in the given format
+func DecodeConfig(data []byte, format string) (*CommandConfig, error) {
+	var config CommandConfig
+
+	switch format {
+	case FormatJSON:
+		if err := json.Unmarshal(stripJSONComments(data), &config); err != nil {
+			return nil, fmt.Errorf("failed to parse config JSON: %w", err)
+		}
+	case FormatYAML:
+		if err := yaml.Unmarshal(data, &config); err != nil {
+			return nil, fmt.Errorf("failed to parse config YAML: %w", err)
+		}
+	case FormatTOML:
+		if err := toml.Unmarshal(data, &config); err != nil {
+			return nil, fmt.Errorf("failed to parse config TOML: %w", err)
+		}
+	default:
+		return nil, fmt.Errorf("unknown config format %q", format)
+	}
+
+	return &config, nil
+}
+
+// HasComments reports whether a configuration in the given format contains
+// comments, which EncodeConfig does not carry over
+func HasComments(data []byte, format string) bool {
+	switch format {
+	case FormatJSON:
+		// Only comments start with a slash that stripping blanks out
+		stripped := stripJSONComments(data)
+		for i, c := range data {
+			if c == '/' && stripped[i] == ' ' {
+				return true
+			}
+		}
+	case FormatYAML:
+		var node yaml.Node
+		if err := yaml.Unmarshal(data, &node); err == nil {
+			return yamlHasComments(&node)
+		}
+	case FormatTOML:
+		return tomlHasComments(data)
+	}
+	return false
+}
+
+// yamlHasComments reports whether a node or any of its children carries a
+// comment
+func yamlHasComments(node *yaml.Node) bool {
+	if node.HeadComment != "" || node.LineComment != "" || node.FootComment != "" {
+		return true
+	}
+	for _, child := range node.Content {
+		if yamlHasComments(child) {
+			return true
+		}
+	}
+	return false
+}
+
+// tomlHasComments reports whether a TOML document contains a # outside of
+// its strings
+func tomlHasComments(data []byte) bool {
+	text := string(data)
+	for i := 0; i < len(text); i++ {
+		switch {
+		case text[i] == '#':
+			return true
+		case strings.HasPrefix(text[i:], `"""`), strings.HasPrefix(text[i:], "'''"):
+			delim := text[i : i+3]
+			end := strings.Index(text[i+3:], delim)
+			if end < 0 {
+				return false
+			}
+			i += 3 + end + 2
+		case text[i] == '"':
+			for i++; i < len(text) && text[i] != '"' && text[i] != '\n'; i++ {
+				if text[i] == '\\' {
+					i++
+				}
+			}
+		case text[i] == '\'':
+			if end := strings.IndexAny(text[i+1:], "'\n"); end >= 0 {
+				i += 1 + end
+			} else {
+				return false
+			}
+		}
+	}
+	return false
+}
+
+// EncodeConfig writes a configuration in the given format.
+// Comments in the original file are not preserved.
+func EncodeConfig(config *CommandConfig, format string) ([]byte, error) {
+	var buf bytes.Buffer
+
+	switch format {
+	case FormatJSON:
+		encoder := json.NewEncoder(&buf)
+		encoder.SetEscapeHTML(false)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(config); err != nil {
+			return nil, fmt.Errorf("failed to encode config JSON: %w", err)
+		}
+	case FormatYAML:
+		encoder := yaml.NewEncoder(&buf)
+		encoder.SetIndent(2)
+		if err := encoder.Encode(config); err != nil {
+			return nil, fmt.Errorf("failed to encode config YAML: %w", err)
+		}
+		if err := encoder.Close(); err != nil {
+			return nil, fmt.Errorf("failed to encode config YAML: %w", err)
+		}
+	case FormatTOML:
+		if err := toml.NewEncoder(&buf).Encode(config); err != nil {
+			return nil, fmt.Errorf("failed to encode config TOML: %w", err)
+		}
+	default:
+		return nil, fmt.Errorf("unknown config format %q", format)
+	}
+
+	return buf.Bytes(), nil
+}
+
+// stripJSONComments blanks out comments and trailing commas so the result
+// can be read by encoding/json. Removed bytes become spaces and newlines are
+// kept, so offsets in parse errors still point into the original file.
+func stripJSONComments(data []byte) []byte {
+	out := make([]byte, len(data))
+	copy(out, data)
+
+	const (
+		code = iota
+		str
+		lineComment
+		blockComment
+	)
+
+	state := code
+	lastComma := -1
+	for i := 0; i < len(out); i++ {
+		c := out[i]
+		switch state {
+		case str:
+			if c == '\\' {
+				i++
+			} else if c == '"' {
+				state = code
+			}
+		case lineComment:
+			if c == '\n' {
+				state = code
+			} else {
+				out[i] = ' '
+			}
+		case blockComment:
+			if c == '*' && i+1 < len(out) && out[i+1] == '/' {
+				out[i], out[i+1] = ' ', ' '
+				i++
+				state = code
+			} else if c != '\n' {
+				out[i] = ' '
+			}
+		default:
+			switch {
+			case c == '"':
+				state = str
+				lastComma = -1
+			case c == '/' && i+1 < len(out) && out[i+1] == '/':
+				out[i], out[i+1] = ' ', ' '
+				i++
+				state = lineComment
+			case c == '/' && i+1 < len(out) && out[i+1] == '*':
+				out[i], out[i+1] = ' ', ' '
+				i++
+				state = blockComment
+			case c == ',':
+				lastComma = i
+			case c == '}' || c == ']':
+				if lastComma >= 0 {
+					out[lastComma] = ' '
+				}
+				lastComma = -1
+			case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			default:
+				lastComma = -1
+			}
+		}
+	}
+
+	return out
+}
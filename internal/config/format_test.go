@@ -0,0 +1,213 @@
+package config
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestDetectFormat(t *testing.T) {
+	tests := map[string]string{
+		"commands.json":      FormatJSON,
+		"commands.jsonc":     FormatJSON,
+		"commands.yaml":      FormatYAML,
+		"/etc/gopherlol.YML": FormatYAML,
+		"commands.toml":      FormatTOML,
+		"commands":           FormatJSON,
+	}
+
+	for filename, expected := range tests {
+		if format := DetectFormat(filename); format != expected {
+			t.Errorf("DetectFormat(%q) = %q, expected %q", filename, format, expected)
+		}
+	}
+}
+
+func TestParseFormat(t *testing.T) {
+	for _, name := range []string{"yaml", "yml", ".yaml", "YAML"} {
+		if format, err := ParseFormat(name); err != nil || format != FormatYAML {
+			t.Errorf("ParseFormat(%q) = %q, %v; expected yaml", name, format, err)
+		}
+	}
+	for _, name := range []string{"xml", "json5"} {
+		if _, err := ParseFormat(name); err == nil {
+			t.Errorf("Expected an error for the unknown format %q", name)
+		}
+	}
+}
+
+func TestStripJSONComments(t *testing.T) {
+	input := `{
+  // who owns this: the platform team
+  "commands": [
+    {
+      "name": "go", /* short for golang */
+      "url": "https://pkg.go.dev/search?q={{.Query}}", // trailing comma next
+    },
+  ],
+  "note": "// not a comment, /* nor this */, ]",
+}`
+
+	stripped := stripJSONComments([]byte(input))
+	if len(stripped) != len(input) || strings.Count(string(stripped), "\n") != strings.Count(input, "\n") {
+		t.Error("Expected stripping to preserve offsets and line breaks")
+	}
+
+	var parsed struct {
+		Commands []Command `json:"commands"`
+		Note     string    `json:"note"`
+	}
+	if err := json.Unmarshal(stripped, &parsed); err != nil {
+		t.Fatalf("Expected valid JSON after stripping, got %v:\n%s", err, stripped)
+	}
+	if len(parsed.Commands) != 1 || parsed.Commands[0].Name != "go" {
+		t.Errorf("Unexpected commands %+v", parsed.Commands)
+	}
+	if parsed.Note != "// not a comment, /* nor this */, ]" {
+		t.Errorf("Expected strings to be left alone, got %q", parsed.Note)
+	}
+}
+
+func TestHasComments(t *testing.T) {
+	tests := []struct {
+		format   string
+		input    string
+		expected bool
+	}{
+		{FormatJSON, `{"commands": [], // owner: platform` + "\n}", true},
+		{FormatJSON, `{"commands": [/* none yet */]}`, true},
+		{FormatJSON, `{"note": "// not a comment, /* nor this */", "commands": [],}`, false},
+		{FormatYAML, "# owner: platform\ncommands: []\n", true},
+		{FormatYAML, "commands:\n  - name: go # short for golang\n", true},
+		{FormatYAML, "commands:\n  - name: \"#go\"\n", false},
+		{FormatTOML, "# owner: platform\n[[commands]]\nname = \"go\"\n", true},
+		{FormatTOML, "[[commands]]\nname = \"go\" # short for golang\n", true},
+		{FormatTOML, "[[commands]]\nname = \"#go\"\ndescription = 'C#'\nurl = \"\"\"\n# not a comment\"\"\"\n", false},
+	}
+
+	for _, tt := range tests {
+		if got := HasComments([]byte(tt.input), tt.format); got != tt.expected {
+			t.Errorf("HasComments(%q, %s) = %v, expected %v", tt.input, tt.format, got, tt.expected)
+		}
+	}
+}
+
+func TestDecodeConfig_Formats(t *testing.T) {
+	expected := &CommandConfig{
+		Commands: []Command{
+			{
+				Name:          "github",
+				Aliases:       []string{"gh"},
+				URL:           "https://github.com/search?q={{.Query}}",
+				RequiresQuery: true,
+				EmptyURL:      "https://github.com",
+				Subcommands: []Subcommand{
+					{Name: "pr", URL: "https://github.com/{{.Args.owner}}/pulls", Params: []Param{{Name: "owner"}}},
+				},
+			},
+		},
+		DidYouMean: &DidYouMeanConfig{Mode: DidYouMeanSuggest, MaxDistance: 1},
+	}
+
+	inputs := map[string]string{
+		FormatJSON: `{
+  // GitHub search
+  "commands": [{
+    "name": "github", "aliases": ["gh"], "url": "https://github.com/search?q={{.Query}}",
+    "requiresQuery": true, "emptyURL": "https://github.com",
+    "subcommands": [{"name": "pr", "url": "https://github.com/{{.Args.owner}}/pulls", "params": [{"name": "owner"}]}],
+  }],
+  "didYouMean": {"mode": "suggest", "maxDistance": 1},
+}`,
+		FormatYAML: `
+# GitHub search
+commands:
+  - name: github
+    aliases: [gh]
+    url: https://github.com/search?q={{.Query}}
+    requiresQuery: true
+    emptyURL: https://github.com
+    subcommands:
+      - name: pr
+        url: https://github.com/{{.Args.owner}}/pulls
+        params:
+          - name: owner
+didYouMean:
+  mode: suggest
+  maxDistance: 1
+`,
+		FormatTOML: `
+# GitHub search
+[[commands]]
+name = "github"
+aliases = ["gh"]
+url = "https://github.com/search?q={{.Query}}"
+requiresQuery = true
+emptyURL = "https://github.com"
+
+  [[commands.subcommands]]
+  name = "pr"
+  url = "https://github.com/{{.Args.owner}}/pulls"
+  params = [{ name = "owner" }]
+
+[didYouMean]
+mode = "suggest"
+maxDistance = 1
+`,
+	}
+
+	for format, input := range inputs {
+		t.Run(format, func(t *testing.T) {
+			config, err := DecodeConfig([]byte(input), format)
+			if err != nil {
+				t.Fatalf("Failed to decode: %v", err)
+			}
+			if !reflect.DeepEqual(config, expected) {
+				t.Errorf("Expected %+v, got %+v", expected, config)
+			}
+		})
+	}
+}
+
+func TestDecodeConfig_Errors(t *testing.T) {
+	for _, format := range []string{FormatJSON, FormatYAML, FormatTOML} {
+		if _, err := DecodeConfig([]byte("commands: [{"), format); err == nil {
+			t.Errorf("Expected a parse error for %s", format)
+		}
+	}
+	if _, err := DecodeConfig([]byte("{}"), "xml"); err == nil {
+		t.Error("Expected an error for an unknown format")
+	}
+}
+
+func TestEncodeConfig_RoundTrip(t *testing.T) {
+	original, err := LoadConfig("../../commands.json.sample")
+	if err != nil {
+		t.Fatalf("Failed to load sample config: %v", err)
+	}
+	original.DidYouMean = &DidYouMeanConfig{Mode: DidYouMeanAutocorrect}
+	original.Fallback = &FallbackConfig{
+		Chain: []FallbackStep{
+			{Type: FallbackPattern, Name: "jira", Pattern: `^[A-Z]+-\d+$`, URL: "https://jira.example.com/browse/{{.Raw}}", Encoding: EncodingRaw},
+			{Type: FallbackError, Message: "Unknown command"},
+		},
+		EmptyURL: "https://example.com/",
+	}
+
+	config := original
+	for _, format := range []string{FormatYAML, FormatTOML, FormatJSON} {
+		data, err := EncodeConfig(config, format)
+		if err != nil {
+			t.Fatalf("Failed to encode %s: %v", format, err)
+		}
+		config, err = DecodeConfig(data, format)
+		if err != nil {
+			t.Fatalf("Failed to decode %s: %v\n%s", format, err, data)
+		}
+	}
+
+	if !reflect.DeepEqual(config, original) {
+		t.Errorf("Expected the config to survive conversion unchanged")
+	}
+}
@@ -0,0 +1,95 @@
+package config
+
+import (
+	"sort"
+	"strings"
+)
+
+// minFuzzyLength is the shortest name considered for fuzzy matching; shorter
+// names are within a couple of edits of almost everything
+const minFuzzyLength = 3
+
+// FuzzyMatch is a command whose name or alias is close to a misspelled name
+type FuzzyMatch struct {
+	Command  *Command
+	Key      string // name or alias that matched
+	Distance int
+}
+
+// FindSimilarCommands returns commands whose name or alias is within
+// maxDistance edits of name, closest first. Each command appears once,
+// under its closest name or alias. The allowed distance shrinks for short
+// names so that a handful of letters does not match everything.
+func (r *CommandRegistry) FindSimilarCommands(name string, maxDistance int) []FuzzyMatch {
+	name = strings.ToLower(name)
+	if len([]rune(name)) < minFuzzyLength {
+		return nil
+	}
+
+	limit := len([]rune(name)) / 3
+	if limit < 1 {
+		limit = 1
+	}
+	if limit > maxDistance {
+		limit = maxDistance
+	}
+
+	best := make(map[*Command]FuzzyMatch)
+	consider := func(key string, cmd *Command) {
+		distance := editDistance(name, key)
+		if distance > limit {
+			return
+		}
+		if match, exists := best[cmd]; exists && match.Distance <= distance {
+			return
+		}
+		best[cmd] = FuzzyMatch{Command: cmd, Key: key, Distance: distance}
+	}
+
+	r.commands.walk("", consider)
+	r.aliases.walk("", consider)
+
+	matches := make([]FuzzyMatch, 0, len(best))
+	for _, match := range best {
+		matches = append(matches, match)
+	}
+	sort.Slice(matches, func(i, j int) bool {
+		if matches[i].Distance != matches[j].Distance {
+			return matches[i].Distance < matches[j].Distance
+		}
+		return matches[i].Key < matches[j].Key
+	})
+
+	return matches
+}
+
+// editDistance computes the optimal string alignment distance between a
+// and b: insertions, deletions, substitutions and adjacent transpositions
+func editDistance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+
+	// d[i][j] is the distance between the first i runes of a and j runes of b
+	d := make([][]int, len(ra)+1)
+	for i := range d {
+		d[i] = make([]int, len(rb)+1)
+		d[i][0] = i
+	}
+	for j := range d[0] {
+		d[0][j] = j
+	}
+
+	for i := 1; i <= len(ra); i++ {
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			d[i][j] = min(d[i-1][j]+1, d[i][j-1]+1, d[i-1][j-1]+cost)
+			if i > 1 && j > 1 && ra[i-1] == rb[j-2] && ra[i-2] == rb[j-1] {
+				d[i][j] = min(d[i][j], d[i-2][j-2]+1)
+			}
+		}
+	}
+
+	return d[len(ra)][len(rb)]
+}
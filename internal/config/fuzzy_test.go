@@ -0,0 +1,73 @@
+package config
+
+import "testing"
+
+func TestEditDistance(t *testing.T) {
+	testCases := []struct {
+		a, b     string
+		expected int
+	}{
+		{"", "", 0},
+		{"abc", "", 3},
+		{"github", "github", 0},
+		{"stackoverfow", "stackoverflow", 1},
+		{"gihtub", "github", 1},
+		{"youtbue", "youtube", 1},
+		{"kitten", "sitting", 3},
+	}
+
+	for _, tc := range testCases {
+		if distance := editDistance(tc.a, tc.b); distance != tc.expected {
+			t.Errorf("editDistance(%q, %q) = %d, expected %d", tc.a, tc.b, distance, tc.expected)
+		}
+	}
+}
+
+func TestFindSimilarCommands(t *testing.T) {
+	config := &CommandConfig{
+		Commands: []Command{
+			{Name: "stackoverflow", Aliases: []string{"so", "stack"}},
+			{Name: "slack", Aliases: []string{"sl"}},
+			{Name: "github", Aliases: []string{"gh"}},
+		},
+	}
+	registry := NewCommandRegistry(config)
+
+	testCases := []struct {
+		name     string
+		expected []string
+	}{
+		{"stackoverfow", []string{"stackoverflow"}},
+		{"stakc", []string{"stack"}},
+		{"slakc", []string{"slack"}},
+		{"stak", []string{"stack"}},
+		{"sack", []string{"slack", "stack"}},
+		{"gx", nil},
+		{"kubernetes", nil},
+	}
+
+	for _, tc := range testCases {
+		matches := registry.FindSimilarCommands(tc.name, 2)
+		if len(matches) != len(tc.expected) {
+			t.Errorf("FindSimilarCommands(%q) returned %d matches, expected %v", tc.name, len(matches), tc.expected)
+			continue
+		}
+		for i, match := range matches {
+			if match.Key != tc.expected[i] {
+				t.Errorf("FindSimilarCommands(%q)[%d] = %q, expected %q", tc.name, i, match.Key, tc.expected[i])
+			}
+		}
+	}
+}
+
+func TestGetDidYouMean(t *testing.T) {
+	registry := NewCommandRegistry(&CommandConfig{})
+	if settings := registry.GetDidYouMean(); settings.Mode != DidYouMeanOff || settings.MaxDistance != defaultMaxDistance {
+		t.Errorf("Expected defaults, got %+v", settings)
+	}
+
+	registry = NewCommandRegistry(&CommandConfig{DidYouMean: &DidYouMeanConfig{Mode: DidYouMeanAutocorrect}})
+	if settings := registry.GetDidYouMean(); settings.Mode != DidYouMeanAutocorrect || settings.MaxDistance != defaultMaxDistance {
+		t.Errorf("Expected autocorrect with default distance, got %+v", settings)
+	}
+}
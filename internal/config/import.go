@@ -0,0 +1,113 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Outcomes of importing a command, see ImportCommands
+const (
+	ImportAdded    = "added"
+	ImportReplaced = "replaced"
+	ImportSkipped  = "skipped"
+)
+
+// ImportResult describes what happened to one imported command
+type ImportResult struct {
+	Command string   `json:"command"`
+	Action  string   `json:"action"`
+	Notes   []string `json:"notes,omitempty"`
+}
+
+func (r ImportResult) String() string {
+	if len(r.Notes) == 0 {
+		return fmt.Sprintf("%s %s", r.Action, r.Command)
+	}
+	return fmt.Sprintf("%s %s: %s", r.Action, r.Command, strings.Join(r.Notes, "; "))
+}
+
+// ImportCommands adds the commands of src to dst. A command whose name dst
+// already defines is skipped, or replaces the existing definition when
+// replace is set; one whose name is an alias in dst is always skipped.
+// Aliases that another command in dst answers to are dropped from the
+// imported command, and so is its default flag when dst has a default
+// already, so existing queries keep going where they went. Settings outside
+// the command list are not imported.
+func ImportCommands(dst, src *CommandConfig, replace bool) []ImportResult {
+	var results []ImportResult
+
+	for _, cmd := range src.Commands {
+		cmd.Aliases = append([]string(nil), cmd.Aliases...)
+		result := ImportResult{Command: cmd.Name, Action: ImportAdded}
+
+		existing := dst.commandIndex(cmd.Name)
+		if existing >= 0 && !replace {
+			result.Action = ImportSkipped
+			result.Notes = append(result.Notes, "already defined")
+			results = append(results, result)
+			continue
+		}
+		if existing < 0 {
+			if owner := dst.aliasOwner(cmd.Name, -1); owner != "" {
+				result.Action = ImportSkipped
+				result.Notes = append(result.Notes, fmt.Sprintf("already an alias of %q", owner))
+				results = append(results, result)
+				continue
+			}
+		}
+
+		for _, alias := range cmd.Aliases {
+			if owner := dst.keyOwner(alias, existing); owner != "" {
+				cmd.Aliases, _ = withoutAlias(cmd.Aliases, alias)
+				result.Notes = append(result.Notes, fmt.Sprintf("alias %q dropped, %q uses it", alias, owner))
+			}
+		}
+
+		if cmd.Default {
+			for i, other := range dst.Commands {
+				if i != existing && other.Default {
+					cmd.Default = false
+					result.Notes = append(result.Notes, fmt.Sprintf("not made the default, %q is", other.Name))
+					break
+				}
+			}
+		}
+
+		if existing >= 0 {
+			result.Action = ImportReplaced
+			dst.Commands[existing] = cmd
+		} else {
+			dst.Commands = append(dst.Commands, cmd)
+		}
+		results = append(results, result)
+	}
+
+	return results
+}
+
+// keyOwner returns the name of the command, other than the one at index
+// skip, that answers to key by name or alias, or "" when there is none
+func (c *CommandConfig) keyOwner(key string, skip int) string {
+	for i, cmd := range c.Commands {
+		if i != skip && strings.EqualFold(cmd.Name, key) {
+			return cmd.Name
+		}
+	}
+	return c.aliasOwner(key, skip)
+}
+
+// aliasOwner returns the name of the command, other than the one at index
+// skip, that has the alias key, or "" when there is none
+func (c *CommandConfig) aliasOwner(key string, skip int) string {
+	for i, cmd := range c.Commands {
+		if i == skip {
+			continue
+		}
+		for _, alias := range cmd.Aliases {
+			if strings.EqualFold(alias, key) {
+				return cmd.Name
+			}
+		}
+	}
+	return ""
+}
@@ -0,0 +1,71 @@
+package config
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestImportCommands(t *testing.T) {
+	dst := &CommandConfig{Commands: []Command{
+		{Name: "g", Aliases: []string{"google"}, URL: "https://google.com/search?q={{.Query}}", Default: true},
+		{Name: "gh", URL: "https://github.com"},
+	}}
+	src := &CommandConfig{Commands: []Command{
+		{Name: "jira", Aliases: []string{"j", "google"}, URL: "https://jira.example.com"},
+		{Name: "GH", URL: "https://github.example.com"},
+		{Name: "google", URL: "https://google.example.com"},
+		{Name: "ddg", URL: "https://duckduckgo.com/?q={{.Query}}", Default: true},
+	}}
+
+	results := ImportCommands(dst, src, false)
+
+	expected := []ImportResult{
+		{Command: "jira", Action: ImportAdded, Notes: []string{`alias "google" dropped, "g" uses it`}},
+		{Command: "GH", Action: ImportSkipped, Notes: []string{"already defined"}},
+		{Command: "google", Action: ImportSkipped, Notes: []string{`already an alias of "g"`}},
+		{Command: "ddg", Action: ImportAdded, Notes: []string{`not made the default, "g" is`}},
+	}
+	if !reflect.DeepEqual(results, expected) {
+		t.Errorf("Expected %+v, got %+v", expected, results)
+	}
+
+	if names := commandNames(dst); !reflect.DeepEqual(names, []string{"g", "gh", "jira", "ddg"}) {
+		t.Errorf("Expected [g gh jira ddg], got %v", names)
+	}
+	if aliases := dst.Commands[2].Aliases; !reflect.DeepEqual(aliases, []string{"j"}) {
+		t.Errorf("Expected jira to keep only alias j, got %v", aliases)
+	}
+	if dst.Commands[3].Default {
+		t.Error("Expected ddg not to become a second default")
+	}
+	if src.Commands[0].Aliases[1] != "google" {
+		t.Error("Expected the imported config to be left alone")
+	}
+}
+
+func TestImportCommands_Replace(t *testing.T) {
+	dst := &CommandConfig{Commands: []Command{
+		{Name: "g", URL: "https://google.com/search?q={{.Query}}", Default: true},
+		{Name: "gh", Aliases: []string{"hub"}, URL: "https://github.com"},
+	}}
+	src := &CommandConfig{Commands: []Command{
+		{Name: "gh", Aliases: []string{"hub"}, URL: "https://github.example.com"},
+		{Name: "g", URL: "https://duckduckgo.com/?q={{.Query}}", Default: true},
+	}}
+
+	results := ImportCommands(dst, src, true)
+
+	expected := []ImportResult{
+		{Command: "gh", Action: ImportReplaced},
+		{Command: "g", Action: ImportReplaced},
+	}
+	if !reflect.DeepEqual(results, expected) {
+		t.Errorf("Expected %+v, got %+v", expected, results)
+	}
+	if dst.Commands[1].URL != "https://github.example.com" || !reflect.DeepEqual(dst.Commands[1].Aliases, []string{"hub"}) {
+		t.Errorf("Expected gh to be replaced with its alias, got %+v", dst.Commands[1])
+	}
+	if !dst.Commands[0].Default {
+		t.Error("Expected the replacement of the default command to stay the default")
+	}
+}
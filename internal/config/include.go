@@ -0,0 +1,325 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"gopkg.in/yaml.v3"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// FragmentDir is the directory next to a config file whose files are
+// merged into it, so each sub-team can own a file
+const FragmentDir = "commands.d"
+
+// Origin is the file and line a command was defined at
+type Origin struct {
+	File string
+	Line int
+}
+
+func (o Origin) String() string {
+	if o.Line == 0 {
+		return o.File
+	}
+	return fmt.Sprintf("%s:%d", o.File, o.Line)
+}
+
+// DuplicateError reports a command name, alias or setting defined in more
+// than one file of the same config
+type DuplicateError struct {
+	Key      string
+	Command  string
+	Origin   Origin
+	Previous Origin
+}
+
+func (e *DuplicateError) Error() string {
+	if e.Command == "" {
+		return fmt.Sprintf("%s: %q is already set at %s", e.Origin, e.Key, e.Previous)
+	}
+	return fmt.Sprintf("%s: %q is already defined by command %q at %s", e.Origin, e.Key, e.Command, e.Previous)
+}
+
+// LoadConfigFiles loads a config file together with the files listed in its
+// include section and every config file in the commands.d directory next to
+// it, in name order. Includes are resolved relative to the file that lists
+// them and may be glob patterns. Any of these may be http(s) URLs instead,
+// see RemoteSource; a remote config has no commands.d directory and its
+// includes cannot be globs. A command name or alias defined in more than one
+// of these files is an error naming both places.
+//
+// The format applies to filename only; other files are read according to
+// their extension. The returned paths are every file, directory and URL that
+// was consulted, for watching. When remote configs were served from their
+// cached copies, the config is returned along with their *StaleErrors.
+func LoadConfigFiles(filename, format string) (*CommandConfig, []string, error) {
+	loader := &fileLoader{seen: make(map[string]bool)}
+
+	if err := loader.load(filename, format); err != nil {
+		return nil, loader.watched, err
+	}
+	if IsRemote(filename) {
+		return &loader.config, loader.watched, errors.Join(loader.errs...)
+	}
+
+	dir := filepath.Join(filepath.Dir(filename), FragmentDir)
+	loader.watched = append(loader.watched, dir)
+	fragments, err := fragmentFiles(dir)
+	if err != nil {
+		return nil, loader.watched, err
+	}
+	for _, fragment := range fragments {
+		if err := loader.load(fragment, DetectFormat(fragment)); err != nil {
+			return nil, loader.watched, err
+		}
+	}
+
+	return &loader.config, loader.watched, errors.Join(loader.errs...)
+}
+
+// fileLoader accumulates the files of one config
+type fileLoader struct {
+	config  CommandConfig
+	seen    map[string]bool
+	watched []string
+	errs    []error
+
+	keys          map[string]keyOwner
+	didYouMean    Origin
+	fallback      Origin
+	abbreviations Origin
+	navigation    Origin
+}
+
+// keyOwner records which command first claimed a name or alias
+type keyOwner struct {
+	command string
+	origin  Origin
+}
+
+// load reads one file and the files it includes
+func (l *fileLoader) load(filename, format string) error {
+	key := filename
+	if !IsRemote(filename) {
+		abs, err := filepath.Abs(filename)
+		if err != nil {
+			return err
+		}
+		key = abs
+	}
+	if l.seen[key] {
+		return fmt.Errorf("%s: included more than once", filename)
+	}
+	l.seen[key] = true
+	l.watched = append(l.watched, filename)
+
+	data, err := readSource(filename, format)
+	if IsStale(err) {
+		l.errs = append(l.errs, err)
+	} else if err != nil {
+		return err
+	}
+	config, err := DecodeConfig(data, format)
+	if err != nil {
+		return fmt.Errorf("%s: %w", filename, err)
+	}
+
+	lines := commandLines(data, format)
+	for i := range config.Commands {
+		cmd := &config.Commands[i]
+		cmd.Origin = Origin{File: filename}
+		if len(lines) == len(config.Commands) {
+			cmd.Origin.Line = lines[i]
+		}
+		l.claim(cmd)
+	}
+
+	l.config.Commands = append(l.config.Commands, config.Commands...)
+	l.config.Patterns = append(l.config.Patterns, config.Patterns...)
+	l.config.Disable = append(l.config.Disable, config.Disable...)
+	if config.DidYouMean != nil {
+		l.setOnce("didYouMean", &l.didYouMean, filename)
+		l.config.DidYouMean = config.DidYouMean
+	}
+	if config.Fallback != nil {
+		l.setOnce("fallback", &l.fallback, filename)
+		l.config.Fallback = config.Fallback
+	}
+	if config.Abbreviations != nil {
+		l.setOnce("abbreviations", &l.abbreviations, filename)
+		l.config.Abbreviations = config.Abbreviations
+	}
+	if config.Navigation != nil {
+		l.setOnce("navigation", &l.navigation, filename)
+		l.config.Navigation = config.Navigation
+	}
+
+	for _, pattern := range config.Include {
+		pattern = resolveInclude(filename, pattern)
+
+		matches := []string{pattern}
+		if !IsRemote(pattern) && strings.ContainsAny(pattern, "*?[") {
+			if matches, err = filepath.Glob(pattern); err != nil {
+				return fmt.Errorf("%s: bad include pattern %q: %w", filename, pattern, err)
+			}
+			sort.Strings(matches)
+		}
+
+		for _, match := range matches {
+			if err := l.load(match, DetectFormat(match)); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// claim records the command's name and aliases, reporting any already
+// claimed by a command in another file. Collisions within one file are left
+// to Lint.
+func (l *fileLoader) claim(cmd *Command) {
+	if l.keys == nil {
+		l.keys = make(map[string]keyOwner)
+	}
+
+	for _, key := range append([]string{cmd.Name}, cmd.Aliases...) {
+		key = strings.ToLower(key)
+		if owner, exists := l.keys[key]; exists && owner.origin.File != cmd.Origin.File {
+			l.errs = append(l.errs, &DuplicateError{
+				Key:      key,
+				Command:  owner.command,
+				Origin:   cmd.Origin,
+				Previous: owner.origin,
+			})
+			continue
+		}
+		if _, exists := l.keys[key]; !exists {
+			l.keys[key] = keyOwner{command: cmd.Name, origin: cmd.Origin}
+		}
+	}
+}
+
+// setOnce records the file that sets a section, reporting a second one
+func (l *fileLoader) setOnce(key string, origin *Origin, filename string) {
+	if origin.File != "" {
+		l.errs = append(l.errs, &DuplicateError{Key: key, Origin: Origin{File: filename}, Previous: *origin})
+		return
+	}
+	*origin = Origin{File: filename}
+}
+
+// fragmentFiles lists the config files in dir in name order, skipping
+// hidden files and files that are not in a config format. A missing
+// directory has no fragments.
+func fragmentFiles(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", dir, err)
+	}
+
+	var files []string
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || strings.HasPrefix(name, ".") {
+			continue
+		}
+		if _, known := formatExtensions[strings.ToLower(filepath.Ext(name))]; known {
+			files = append(files, filepath.Join(dir, name))
+		}
+	}
+	return files, nil
+}
+
+// tomlCommandHeader matches the table header that starts each command in TOML
+var tomlCommandHeader = regexp.MustCompile(`(?m)^[ \t]*\[\[[ \t]*"?commands"?[ \t]*\]\]`)
+
+// commandLines returns the line each entry of the commands list starts on,
+// or nil when they cannot be located
+func commandLines(data []byte, format string) []int {
+	switch format {
+	case FormatJSON:
+		return jsonCommandLines(stripJSONComments(data))
+	case FormatYAML:
+		var root yaml.Node
+		if err := yaml.Unmarshal(data, &root); err != nil || len(root.Content) == 0 {
+			return nil
+		}
+		doc := root.Content[0]
+		for i := 0; i+1 < len(doc.Content); i += 2 {
+			if doc.Content[i].Value == "commands" {
+				var lines []int
+				for _, item := range doc.Content[i+1].Content {
+					lines = append(lines, item.Line)
+				}
+				return lines
+			}
+		}
+	case FormatTOML:
+		var lines []int
+		for _, loc := range tomlCommandHeader.FindAllIndex(data, -1) {
+			lines = append(lines, lineAt(data, loc[0]))
+		}
+		return lines
+	}
+	return nil
+}
+
+// jsonCommandLines walks the top-level object to the commands array and
+// notes where each element starts
+func jsonCommandLines(data []byte) []int {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	if tok, err := dec.Token(); err != nil || tok != json.Delim('{') {
+		return nil
+	}
+
+	for dec.More() {
+		key, err := dec.Token()
+		if err != nil {
+			return nil
+		}
+		if key != "commands" {
+			var skip json.RawMessage
+			if err := dec.Decode(&skip); err != nil {
+				return nil
+			}
+			continue
+		}
+
+		if tok, err := dec.Token(); err != nil || tok != json.Delim('[') {
+			return nil
+		}
+		var lines []int
+		for dec.More() {
+			// The offset is just past the previous token; the element
+			// starts after any separating comma and whitespace
+			offset := int(dec.InputOffset())
+			for offset < len(data) && strings.IndexByte(", \t\r\n", data[offset]) >= 0 {
+				offset++
+			}
+			lines = append(lines, lineAt(data, offset))
+
+			var skip json.RawMessage
+			if err := dec.Decode(&skip); err != nil {
+				return nil
+			}
+		}
+		return lines
+	}
+
+	return nil
+}
+
+// lineAt returns the 1-based line number of a byte offset
+func lineAt(data []byte, offset int) int {
+	return bytes.Count(data[:offset], []byte("\n")) + 1
+}
@@ -0,0 +1,152 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// writeFiles creates files under dir from a map of relative paths to contents
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// commandNames lists the names of a config's commands in order
+func commandNames(config *CommandConfig) []string {
+	var names []string
+	for _, cmd := range config.Commands {
+		names = append(names, cmd.Name)
+	}
+	return names
+}
+
+func TestLoadConfigFiles(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"commands.json": `{
+  "include": ["shared/search.yaml", "teams/*.toml"],
+  "commands": [
+    {"name": "google", "url": "https://www.google.com/?q={{.Query}}", "default": true}
+  ]
+}`,
+		"shared/search.yaml":     "commands:\n  - name: ddg\n    url: https://duckduckgo.com/?q={{.Query}}\n",
+		"teams/b.toml":           "[[commands]]\nname = \"grafana\"\nurl = \"https://grafana.internal\"\n",
+		"teams/a.toml":           "[[commands]]\nname = \"argo\"\nurl = \"https://argo.internal\"\n",
+		"commands.d/20-sre.json": `{"commands": [{"name": "pager", "url": "https://pager.internal"}], "fallback": {"emptyURL": "https://intranet"}}`,
+		"commands.d/10-dev.yaml": "commands:\n  - name: ci\n    url: https://ci.internal\n",
+		"commands.d/.swap.json":  `{`,
+		"commands.d/README.md":   "not a config",
+	})
+
+	config, watched, err := LoadConfigFiles(filepath.Join(dir, "commands.json"), FormatJSON)
+	if err != nil {
+		t.Fatalf("Failed to load: %v", err)
+	}
+
+	expected := []string{"google", "ddg", "argo", "grafana", "ci", "pager"}
+	if names := commandNames(config); !reflect.DeepEqual(names, expected) {
+		t.Errorf("Expected commands %v, got %v", expected, names)
+	}
+	if config.Fallback == nil || config.Fallback.EmptyURL != "https://intranet" {
+		t.Error("Expected settings from a fragment to be kept")
+	}
+	if origin := config.Commands[4].Origin; origin.File != filepath.Join(dir, "commands.d", "10-dev.yaml") || origin.Line != 2 {
+		t.Errorf("Expected ci to come from 10-dev.yaml:2, got %s", origin)
+	}
+	if len(watched) != 7 || watched[len(watched)-3] != filepath.Join(dir, "commands.d") {
+		t.Errorf("Expected every file and the fragment directory to be watched, got %v", watched)
+	}
+}
+
+func TestLoadConfigFiles_Duplicates(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"commands.json": `{
+  "commands": [
+    {"name": "google", "aliases": ["g"], "url": "https://www.google.com/?q={{.Query}}"},
+    {
+      "name": "wiki",
+      "url": "https://wiki.internal"
+    }
+  ],
+  "didYouMean": {"mode": "suggest"}
+}`,
+		"commands.d/search.yaml": "didYouMean:\n  mode: off\ncommands:\n  - name: gitlab\n    aliases: [gl]\n    url: https://gitlab.com\n  - name: bing\n    aliases: [g]\n    url: https://bing.com\n",
+		"commands.d/wiki.toml":   "[[commands]]\nname = \"docs\"\nurl = \"https://docs.internal\"\n\n[[commands]]\nname = \"wiki\"\nurl = \"https://other-wiki.internal\"\n",
+	})
+
+	_, _, err := LoadConfigFiles(filepath.Join(dir, "commands.json"), FormatJSON)
+	if err == nil {
+		t.Fatal("Expected duplicates across files to fail")
+	}
+
+	var dupErr *DuplicateError
+	if !errors.As(err, &dupErr) {
+		t.Fatalf("Expected a DuplicateError, got %v", err)
+	}
+
+	main := filepath.Join(dir, "commands.json")
+	fragments := filepath.Join(dir, "commands.d")
+	for _, expected := range []string{
+		filepath.Join(fragments, "search.yaml") + `: "didYouMean" is already set at ` + main,
+		filepath.Join(fragments, "search.yaml") + `:7: "g" is already defined by command "google" at ` + main + ":3",
+		filepath.Join(fragments, "wiki.toml") + `:5: "wiki" is already defined by command "wiki" at ` + main + ":4",
+	} {
+		if !strings.Contains(err.Error(), expected) {
+			t.Errorf("Expected error to contain %q, got:\n%v", expected, err)
+		}
+	}
+}
+
+func TestLoadConfigFiles_IncludeErrors(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"cycle.json":   `{"include": ["other.json"], "commands": []}`,
+		"other.json":   `{"include": ["cycle.json"], "commands": []}`,
+		"missing.json": `{"include": ["nowhere.json"], "commands": []}`,
+		"broken.json":  `{"include": ["bad.yaml"], "commands": []}`,
+		"bad.yaml":     "commands: [",
+	})
+
+	tests := map[string]string{
+		"cycle.json":   "included more than once",
+		"missing.json": "nowhere.json",
+		"broken.json":  "bad.yaml",
+	}
+	for file, expected := range tests {
+		_, _, err := LoadConfigFiles(filepath.Join(dir, file), FormatJSON)
+		if err == nil || !strings.Contains(err.Error(), expected) {
+			t.Errorf("%s: expected error mentioning %q, got %v", file, expected, err)
+		}
+	}
+}
+
+func TestCommandLines(t *testing.T) {
+	tests := []struct {
+		format   string
+		data     string
+		expected []int
+	}{
+		{FormatJSON, "{\n  // note\n  \"other\": {\"commands\": [1]},\n  \"commands\": [\n    {\"name\": \"a\"},\n\n    {\"name\": \"b\"}\n  ]\n}", []int{5, 7}},
+		{FormatYAML, "# note\ncommands:\n  - name: a\n  - name: b\n", []int{3, 4}},
+		{FormatTOML, "# note\n[[commands]]\nname = \"a\"\n\n  [[commands]]\nname = \"b\"\n", []int{2, 5}},
+	}
+
+	for _, tt := range tests {
+		if lines := commandLines([]byte(tt.data), tt.format); !reflect.DeepEqual(lines, tt.expected) {
+			t.Errorf("%s: expected lines %v, got %v", tt.format, tt.expected, lines)
+		}
+	}
+}
@@ -0,0 +1,251 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Default config layer names, from lowest to highest precedence
+const (
+	LayerSystem = "system"
+	LayerTeam   = "team"
+	LayerUser   = "user"
+)
+
+// Layer is one config source in a stack where later layers take precedence
+type Layer struct {
+	Name   string
+	Config *CommandConfig
+}
+
+// Conflict kinds reported by MergeLayers
+const (
+	ConflictOverride = "override"
+	ConflictAlias    = "alias"
+	ConflictDefault  = "default"
+	ConflictDisable  = "disable"
+)
+
+// Conflict describes a definition in one layer that replaces, takes over or
+// fails to affect something from an earlier layer
+type Conflict struct {
+	Kind     string `json:"kind"`
+	Layer    string `json:"layer"`
+	Previous string `json:"previous,omitempty"`
+	Command  string `json:"command"`
+	Key      string `json:"key,omitempty"`
+	Message  string `json:"message"`
+}
+
+func (c Conflict) String() string {
+	return fmt.Sprintf("%s: %s", c.Layer, c.Message)
+}
+
+// MergeLayers combines layered configs into one. Each layer can add
+// commands, replace a command from an earlier layer by redefining its name,
+// and remove commands or aliases listed in its disable list. Aliases and
+// names taken over from an earlier layer are removed from the command that
+// had them, so the merged config has no ambiguous keys across layers. Every
+// such decision is returned as a Conflict. Patterns of later layers are
+// tried first and replace earlier ones with the same name. Other settings
+// outside the command list are taken from the last layer that sets them.
+func MergeLayers(layers []Layer) (*CommandConfig, []Conflict) {
+	merged := &CommandConfig{}
+	var conflicts []Conflict
+
+	for _, layer := range layers {
+		cfg := layer.Config
+
+		for _, key := range cfg.Disable {
+			if !merged.disable(key) {
+				conflicts = append(conflicts, Conflict{
+					Kind:    ConflictDisable,
+					Layer:   layer.Name,
+					Key:     key,
+					Message: fmt.Sprintf("disable %q matches no command or alias from an earlier layer", key),
+				})
+			}
+		}
+
+		for _, cmd := range cfg.Commands {
+			cmd.Source = layer.Name
+			cmd.Aliases = append([]string(nil), cmd.Aliases...)
+
+			if cmd.Default {
+				for i := range merged.Commands {
+					previous := &merged.Commands[i]
+					if previous.Default && previous.Source != layer.Name && !strings.EqualFold(previous.Name, cmd.Name) {
+						previous.Default = false
+						conflicts = append(conflicts, Conflict{
+							Kind:     ConflictDefault,
+							Layer:    layer.Name,
+							Previous: previous.Source,
+							Command:  cmd.Name,
+							Message:  fmt.Sprintf("default command %q replaces %q from %s", cmd.Name, previous.Name, previous.Source),
+						})
+					}
+				}
+			}
+
+			if i := merged.commandIndex(cmd.Name); i >= 0 {
+				previous := merged.Commands[i]
+				if previous.Source != layer.Name {
+					conflicts = append(conflicts, Conflict{
+						Kind:     ConflictOverride,
+						Layer:    layer.Name,
+						Previous: previous.Source,
+						Command:  cmd.Name,
+						Key:      cmd.Name,
+						Message:  fmt.Sprintf("command %q overrides the definition from %s", cmd.Name, previous.Source),
+					})
+				}
+				merged.Commands[i] = cmd
+			} else {
+				merged.Commands = append(merged.Commands, cmd)
+			}
+
+			conflicts = append(conflicts, merged.claimKeys(layer.Name, cmd.Name)...)
+		}
+
+		var replaced []Conflict
+		merged.Patterns, replaced = mergePatterns(layer.Name, merged.Patterns, cfg.Patterns)
+		conflicts = append(conflicts, replaced...)
+
+		if cfg.DidYouMean != nil {
+			merged.DidYouMean = cfg.DidYouMean
+		}
+		if cfg.Fallback != nil {
+			merged.Fallback = cfg.Fallback
+		}
+		if cfg.Abbreviations != nil {
+			merged.Abbreviations = cfg.Abbreviations
+		}
+		if cfg.Navigation != nil {
+			merged.Navigation = cfg.Navigation
+		}
+	}
+
+	return merged, conflicts
+}
+
+// mergePatterns puts the patterns of a layer ahead of those of earlier
+// layers, dropping earlier patterns with the same name
+func mergePatterns(layer string, earlier, later []Pattern) ([]Pattern, []Conflict) {
+	if len(later) == 0 {
+		return earlier, nil
+	}
+
+	merged := append([]Pattern(nil), later...)
+	var conflicts []Conflict
+	for _, previous := range earlier {
+		replaced := false
+		for _, pattern := range later {
+			if pattern.Label() == previous.Label() {
+				replaced = true
+				break
+			}
+		}
+		if replaced {
+			conflicts = append(conflicts, Conflict{
+				Kind:    ConflictOverride,
+				Layer:   layer,
+				Command: previous.Label(),
+				Key:     previous.Label(),
+				Message: fmt.Sprintf("pattern %q overrides an earlier definition", previous.Label()),
+			})
+			continue
+		}
+		merged = append(merged, previous)
+	}
+	return merged, conflicts
+}
+
+// commandIndex returns the position of the command with the given name, or -1
+func (c *CommandConfig) commandIndex(name string) int {
+	for i := range c.Commands {
+		if strings.EqualFold(c.Commands[i].Name, name) {
+			return i
+		}
+	}
+	return -1
+}
+
+// disable removes the command named key, or else the alias key from every
+// command that has it. It reports whether anything was removed.
+func (c *CommandConfig) disable(key string) bool {
+	if i := c.commandIndex(key); i >= 0 {
+		c.Commands = append(c.Commands[:i], c.Commands[i+1:]...)
+		return true
+	}
+
+	removed := false
+	for i := range c.Commands {
+		if aliases, ok := withoutAlias(c.Commands[i].Aliases, key); ok {
+			c.Commands[i].Aliases = aliases
+			removed = true
+		}
+	}
+	return removed
+}
+
+// claimKeys gives the named command sole ownership of its name and aliases
+// over commands from other layers
+func (c *CommandConfig) claimKeys(layer, name string) []Conflict {
+	var conflicts []Conflict
+
+	owner := &c.Commands[c.commandIndex(name)]
+	keys := append([]string{owner.Name}, owner.Aliases...)
+	for _, key := range keys {
+		for i := range c.Commands {
+			other := &c.Commands[i]
+			if other == owner || other.Source == layer {
+				continue
+			}
+
+			if strings.EqualFold(other.Name, key) {
+				// Names are looked up before aliases, so the alias can never win
+				owner.Aliases, _ = withoutAlias(owner.Aliases, key)
+				conflicts = append(conflicts, Conflict{
+					Kind:     ConflictAlias,
+					Layer:    layer,
+					Previous: other.Source,
+					Command:  owner.Name,
+					Key:      key,
+					Message:  fmt.Sprintf("alias %q of %q is dropped because it is the name of %q from %s", key, owner.Name, other.Name, other.Source),
+				})
+				continue
+			}
+
+			if aliases, ok := withoutAlias(other.Aliases, key); ok {
+				other.Aliases = aliases
+				conflicts = append(conflicts, Conflict{
+					Kind:     ConflictAlias,
+					Layer:    layer,
+					Previous: other.Source,
+					Command:  owner.Name,
+					Key:      key,
+					Message:  fmt.Sprintf("%q now goes to %q instead of %q from %s", key, owner.Name, other.Name, other.Source),
+				})
+			}
+		}
+	}
+
+	return conflicts
+}
+
+// withoutAlias returns aliases with key removed, and whether it was present
+func withoutAlias(aliases []string, key string) ([]string, bool) {
+	var kept []string
+	found := false
+	for _, alias := range aliases {
+		if strings.EqualFold(alias, key) {
+			found = true
+			continue
+		}
+		kept = append(kept, alias)
+	}
+	if !found {
+		return aliases, false
+	}
+	return kept, true
+}
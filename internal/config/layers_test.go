@@ -0,0 +1,150 @@
+package config
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestMergeLayers(t *testing.T) {
+	system := &CommandConfig{
+		Commands: []Command{
+			{Name: "google", Aliases: []string{"g", "search"}, URL: "https://www.google.com/?q={{.Query}}", Default: true},
+			{Name: "wiki", Aliases: []string{"w"}, URL: "https://wiki.example.com/?q={{.Query}}"},
+		},
+		DidYouMean: &DidYouMeanConfig{Mode: DidYouMeanSuggest},
+	}
+	team := &CommandConfig{
+		Commands: []Command{
+			{Name: "wiki", Aliases: []string{"w"}, URL: "https://confluence.example.com/?q={{.Query}}"},
+			{Name: "jira", Aliases: []string{"j"}, URL: "https://jira.example.com/browse/{{.Query}}"},
+		},
+	}
+	user := &CommandConfig{
+		Disable: []string{"jira", "search"},
+		Commands: []Command{
+			{Name: "ddg", Aliases: []string{"g"}, URL: "https://duckduckgo.com/?q={{.Query}}", Default: true},
+		},
+		DidYouMean: &DidYouMeanConfig{Mode: DidYouMeanAutocorrect},
+	}
+
+	merged, conflicts := MergeLayers([]Layer{
+		{Name: LayerSystem, Config: system},
+		{Name: LayerTeam, Config: team},
+		{Name: LayerUser, Config: user},
+	})
+
+	var summary []string
+	for _, cmd := range merged.Commands {
+		summary = append(summary, cmd.Name+"@"+cmd.Source)
+	}
+	if expected := []string{"google@system", "wiki@team", "ddg@user"}; !reflect.DeepEqual(summary, expected) {
+		t.Errorf("Expected commands %v, got %v", expected, summary)
+	}
+
+	google := merged.Commands[0]
+	if len(google.Aliases) != 0 {
+		t.Errorf("Expected google to lose g (taken) and search (disabled), got %v", google.Aliases)
+	}
+	if google.Default || !merged.Commands[2].Default {
+		t.Error("Expected ddg to replace google as the default command")
+	}
+	if merged.Commands[1].URL != team.Commands[0].URL {
+		t.Error("Expected the team wiki to override the system wiki")
+	}
+	if merged.DidYouMean.Mode != DidYouMeanAutocorrect {
+		t.Errorf("Expected the last didYouMean setting, got %q", merged.DidYouMean.Mode)
+	}
+
+	var kinds []string
+	for _, conflict := range conflicts {
+		kinds = append(kinds, conflict.Kind+":"+conflict.Layer)
+	}
+	expected := []string{"override:team", "default:user", "alias:user"}
+	if !reflect.DeepEqual(kinds, expected) {
+		t.Errorf("Expected conflicts %v, got %v", expected, kinds)
+	}
+
+	if len(system.Commands[0].Aliases) != 2 || !system.Commands[0].Default || system.Commands[0].Source != "" {
+		t.Error("Expected the input layers to be left untouched")
+	}
+}
+
+func TestMergeLayers_NameConflicts(t *testing.T) {
+	team := &CommandConfig{Commands: []Command{
+		{Name: "gh", URL: "https://github.com"},
+		{Name: "gitlab", Aliases: []string{"gl"}, URL: "https://gitlab.com"},
+	}}
+	user := &CommandConfig{Commands: []Command{
+		{Name: "github", Aliases: []string{"gh"}, URL: "https://github.com"},
+		{Name: "gl", URL: "https://gl.example.com"},
+	}}
+
+	merged, conflicts := MergeLayers([]Layer{{Name: LayerTeam, Config: team}, {Name: LayerUser, Config: user}})
+
+	if aliases := merged.Commands[2].Aliases; len(aliases) != 0 {
+		t.Errorf("Expected the gh alias to be dropped in favour of the gh command, got %v", aliases)
+	}
+	if aliases := merged.Commands[1].Aliases; len(aliases) != 0 {
+		t.Errorf("Expected the user's gl command to take gl from gitlab, got %v", aliases)
+	}
+	if len(conflicts) != 2 {
+		t.Errorf("Expected 2 conflicts, got %+v", conflicts)
+	}
+}
+
+func TestMergeLayers_UnknownDisable(t *testing.T) {
+	_, conflicts := MergeLayers([]Layer{
+		{Name: LayerTeam, Config: &CommandConfig{Commands: []Command{{Name: "google", URL: "https://google.com"}}}},
+		{Name: LayerUser, Config: &CommandConfig{Disable: []string{"bing"}}},
+	})
+
+	if len(conflicts) != 1 || conflicts[0].Kind != ConflictDisable {
+		t.Errorf("Expected an unmatched disable to be reported, got %+v", conflicts)
+	}
+}
+
+func TestMergeLayers_SingleLayer(t *testing.T) {
+	config := &CommandConfig{Commands: []Command{
+		{Name: "a", Aliases: []string{"x"}, URL: "https://a.com", Default: true},
+		{Name: "b", Aliases: []string{"x"}, URL: "https://b.com", Default: true},
+	}}
+
+	merged, conflicts := MergeLayers([]Layer{{Name: LayerTeam, Config: config}})
+
+	if len(conflicts) != 0 {
+		t.Errorf("Expected collisions within a layer to be left to Lint, got %+v", conflicts)
+	}
+	if len(merged.Commands) != 2 || !merged.Commands[0].Default || len(merged.Commands[0].Aliases) != 1 {
+		t.Errorf("Expected a single layer to pass through unchanged, got %+v", merged.Commands)
+	}
+}
+
+func TestMergeLayers_Patterns(t *testing.T) {
+	team := &CommandConfig{Patterns: []Pattern{
+		{Name: "jira-key", Match: `[A-Z]+-\d+`, URL: "https://jira.example.com/browse/{{.Query}}"},
+		{Name: "pr", Match: `#\d+`, URL: "https://github.com/acme/app/pull/{{.Query}}"},
+	}}
+	user := &CommandConfig{Patterns: []Pattern{
+		{Name: "incident", Match: `INC\d+`, URL: "https://incidents.example.com/{{.Query}}"},
+		{Name: "pr", Match: `#\d+`, URL: "https://github.com/me/fork/pull/{{.Query}}"},
+	}}
+
+	merged, conflicts := MergeLayers([]Layer{
+		{Name: LayerTeam, Config: team},
+		{Name: LayerUser, Config: user},
+	})
+
+	var names []string
+	for _, pattern := range merged.Patterns {
+		names = append(names, pattern.Label())
+	}
+	if expected := []string{"incident", "pr", "jira-key"}; !reflect.DeepEqual(names, expected) {
+		t.Errorf("Expected the user patterns first, got %v", names)
+	}
+	if merged.Patterns[1].URL != user.Patterns[1].URL {
+		t.Error("Expected the user pr pattern to replace the team one")
+	}
+	if len(conflicts) != 1 || conflicts[0].Kind != ConflictOverride || conflicts[0].Key != "pr" {
+		t.Errorf("Expected one override conflict for pr, got %+v", conflicts)
+	}
+}
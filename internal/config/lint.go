@@ -0,0 +1,383 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Lint severities
+const (
+	SeverityError   = "error"
+	SeverityWarning = "warning"
+)
+
+// Issue is a problem Lint found in a configuration
+type Issue struct {
+	Severity   string `json:"severity"`
+	Code       string `json:"code"`
+	Command    string `json:"command,omitempty"`
+	Subcommand string `json:"subcommand,omitempty"`
+	Message    string `json:"message"`
+}
+
+// queryReference matches template actions that use the query
+var queryReference = regexp.MustCompile(`\{\{[^}]*\.(Query|Raw|Args)\b`)
+
+// schemeRegexp matches a URL scheme as defined by RFC 3986
+var schemeRegexp = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9+.-]*$`)
+
+// Lint checks a configuration for mistakes that NewCommandRegistry would
+// silently accept: colliding names and aliases, several default commands,
+// empty URLs, templates that ignore a required query, ambiguous or
+// unreachable subcommands, invalid flags, and templates that do not compile.
+func Lint(config *CommandConfig) []Issue {
+	var issues []Issue
+
+	issues = append(issues, lintCommandKeys(config.Commands)...)
+
+	var defaults []string
+	for _, cmd := range config.Commands {
+		if cmd.Default {
+			defaults = append(defaults, cmd.Name)
+		}
+		if cmd.Name == "" {
+			issues = append(issues, Issue{
+				Severity: SeverityError,
+				Code:     "empty-name",
+				Message:  "command has no name",
+			})
+		}
+		if strings.TrimSpace(cmd.URL) == "" {
+			issues = append(issues, Issue{
+				Severity: SeverityError,
+				Code:     "empty-url",
+				Command:  cmd.Name,
+				Message:  "command has no url",
+			})
+		} else if cmd.RequiresQuery && !queryReference.MatchString(cmd.URL) {
+			issues = append(issues, Issue{
+				Severity: SeverityWarning,
+				Code:     "query-ignored",
+				Command:  cmd.Name,
+				Message:  "requiresQuery is set but the url never uses .Query, .Raw or .Args",
+			})
+		}
+		issues = append(issues, lintFlags(cmd.Name, "", cmd.Flags)...)
+		issues = append(issues, lintSubcommands(cmd.Name, "", cmd.Subcommands)...)
+	}
+
+	if len(defaults) > 1 {
+		issues = append(issues, Issue{
+			Severity: SeverityError,
+			Code:     "multiple-defaults",
+			Message:  fmt.Sprintf("%d commands are marked default (%s); only %q takes effect", len(defaults), strings.Join(defaults, ", "), defaults[len(defaults)-1]),
+		})
+	}
+
+	issues = append(issues, lintSettings(config)...)
+	issues = append(issues, lintTemplates(config)...)
+
+	return issues
+}
+
+// lintCommandKeys reports command names and aliases that are claimed by
+// more than one command. NewCommandRegistry resolves names before aliases,
+// and the last command registered wins within each.
+func lintCommandKeys(commands []Command) []Issue {
+	var issues []Issue
+
+	names := make(map[string]string)
+	aliases := make(map[string]string)
+	for _, cmd := range commands {
+		key := strings.ToLower(cmd.Name)
+		if owner, exists := names[key]; exists && key != "" {
+			issues = append(issues, Issue{
+				Severity: SeverityError,
+				Code:     "duplicate-name",
+				Command:  cmd.Name,
+				Message:  fmt.Sprintf("name %q is used by more than one command; the later definition replaces %q", cmd.Name, owner),
+			})
+		}
+		names[key] = cmd.Name
+	}
+
+	for _, cmd := range commands {
+		for _, alias := range cmd.Aliases {
+			key := strings.ToLower(alias)
+			if owner, exists := names[key]; exists && owner != cmd.Name {
+				issues = append(issues, Issue{
+					Severity: SeverityError,
+					Code:     "alias-collision",
+					Command:  cmd.Name,
+					Message:  fmt.Sprintf("alias %q is the name of command %q and will never reach %q", alias, owner, cmd.Name),
+				})
+				continue
+			}
+			if owner, exists := aliases[key]; exists && owner != cmd.Name {
+				issues = append(issues, Issue{
+					Severity: SeverityError,
+					Code:     "alias-collision",
+					Command:  cmd.Name,
+					Message:  fmt.Sprintf("alias %q is also an alias of command %q; only %q will be reached", alias, owner, cmd.Name),
+				})
+			}
+			aliases[key] = cmd.Name
+		}
+	}
+
+	return issues
+}
+
+// lintSubcommands checks one level of the subcommand tree and recurses
+func lintSubcommands(cmdName, parentPath string, subs []Subcommand) []Issue {
+	var issues []Issue
+
+	// Later siblings overwrite earlier ones in the registry's lookup table
+	owners := make(map[string]int)
+	for i, sub := range subs {
+		keys := append([]string{sub.Name}, sub.Aliases...)
+		for _, key := range keys {
+			key = strings.ToLower(key)
+			if previous, exists := owners[key]; exists && previous != i {
+				issues = append(issues, Issue{
+					Severity:   SeverityError,
+					Code:       "duplicate-subcommand-alias",
+					Command:    cmdName,
+					Subcommand: joinPath(parentPath, sub.Name),
+					Message:    fmt.Sprintf("%q is also used by subcommand %q", key, subs[previous].Name),
+				})
+			}
+			owners[key] = i
+		}
+	}
+
+	for i, sub := range subs {
+		path := joinPath(parentPath, sub.Name)
+
+		reachable := false
+		for _, key := range append([]string{sub.Name}, sub.Aliases...) {
+			if key != "" && owners[strings.ToLower(key)] == i {
+				reachable = true
+			}
+		}
+		if !reachable {
+			issues = append(issues, Issue{
+				Severity:   SeverityError,
+				Code:       "unreachable-subcommand",
+				Command:    cmdName,
+				Subcommand: path,
+				Message:    "every name and alias of this subcommand is taken by a later sibling",
+			})
+		}
+
+		if strings.TrimSpace(sub.URL) == "" {
+			issues = append(issues, Issue{
+				Severity:   SeverityError,
+				Code:       "empty-url",
+				Command:    cmdName,
+				Subcommand: path,
+				Message:    "subcommand has no url",
+			})
+		}
+
+		issues = append(issues, lintFlags(cmdName, path, sub.Flags)...)
+		issues = append(issues, lintSubcommands(cmdName, path, sub.Subcommands)...)
+	}
+
+	return issues
+}
+
+// lintFlags checks the flags of a command or subcommand: names, types, and
+// defaults and allowed values that their type rejects
+func lintFlags(cmdName, subPath string, flags []Flag) []Issue {
+	var issues []Issue
+	report := func(message string) {
+		issues = append(issues, Issue{
+			Severity:   SeverityError,
+			Code:       "invalid-flag",
+			Command:    cmdName,
+			Subcommand: subPath,
+			Message:    message,
+		})
+	}
+
+	seen := make(map[string]bool)
+	for i, flag := range flags {
+		field := fmt.Sprintf("flags[%d]", i)
+		switch {
+		case flag.Name == "":
+			report(field + " has no name")
+			continue
+		case strings.HasPrefix(flag.Name, "-") || strings.ContainsAny(flag.Name, "= \t"):
+			report(fmt.Sprintf("%s name %q must not start with \"-\" or contain \"=\" or spaces", field, flag.Name))
+			continue
+		case seen[strings.ToLower(flag.Name)]:
+			report(fmt.Sprintf("flag -%s is declared more than once", flag.Name))
+			continue
+		}
+		seen[strings.ToLower(flag.Name)] = true
+
+		switch flag.Type {
+		case "", FlagString, FlagBool, FlagInt, FlagDuration:
+		default:
+			report(fmt.Sprintf("flag -%s has type %q, not one of string, bool, int, duration", flag.Name, flag.Type))
+			continue
+		}
+
+		for _, value := range flag.Values {
+			if _, err := (Flag{Name: flag.Name, Type: flag.Type}).Parse(value); err != nil {
+				report(fmt.Sprintf("flag -%s allows %q, which is not %s", flag.Name, value, flag.kind()))
+			}
+		}
+		if flag.Default != "" {
+			if _, err := flag.Parse(flag.Default); err != nil {
+				report(fmt.Sprintf("flag -%s has an invalid default: %v", flag.Name, err))
+			}
+		}
+	}
+
+	return issues
+}
+
+// lintSettings checks the options outside the command list
+func lintSettings(config *CommandConfig) []Issue {
+	var issues []Issue
+
+	if config.DidYouMean != nil {
+		switch config.DidYouMean.Mode {
+		case "", DidYouMeanOff, DidYouMeanSuggest, DidYouMeanAutocorrect:
+		default:
+			issues = append(issues, Issue{
+				Severity: SeverityError,
+				Code:     "unknown-mode",
+				Message:  fmt.Sprintf("didYouMean.mode %q is not one of off, suggest, autocorrect", config.DidYouMean.Mode),
+			})
+		}
+	}
+
+	if config.Abbreviations != nil {
+		switch config.Abbreviations.Mode {
+		case "", AbbreviationsOn, AbbreviationsOff:
+		default:
+			issues = append(issues, Issue{
+				Severity: SeverityError,
+				Code:     "unknown-mode",
+				Message:  fmt.Sprintf("abbreviations.mode %q is not one of on, off", config.Abbreviations.Mode),
+			})
+		}
+	}
+
+	if config.Navigation != nil {
+		switch config.Navigation.Mode {
+		case "", NavigationOn, NavigationAny, NavigationOff:
+		default:
+			issues = append(issues, Issue{
+				Severity: SeverityError,
+				Code:     "unknown-mode",
+				Message:  fmt.Sprintf("navigation.mode %q is not one of on, any, off", config.Navigation.Mode),
+			})
+		}
+		for _, field := range []struct{ name, scheme string }{
+			{"navigation.scheme", config.Navigation.Scheme},
+			{"navigation.localScheme", config.Navigation.LocalScheme},
+		} {
+			if field.scheme != "" && !schemeRegexp.MatchString(field.scheme) {
+				issues = append(issues, Issue{
+					Severity: SeverityError,
+					Code:     "invalid-scheme",
+					Message:  fmt.Sprintf("%s %q is not a URL scheme", field.name, field.scheme),
+				})
+			}
+		}
+	}
+
+	for i, pattern := range config.Patterns {
+		issues = append(issues, lintPattern(fmt.Sprintf("patterns[%d]", i), pattern)...)
+	}
+
+	if config.Fallback != nil {
+		for i, step := range config.Fallback.Chain {
+			field := fmt.Sprintf("fallback.chain[%d]", i)
+			switch step.Type {
+			case FallbackDefault, FallbackFuzzy, FallbackError:
+			case FallbackPattern:
+				issues = append(issues, lintPattern(field, step.asPattern())...)
+			case FallbackURL:
+				if strings.TrimSpace(step.URL) == "" {
+					issues = append(issues, Issue{
+						Severity: SeverityError,
+						Code:     "empty-url",
+						Message:  fmt.Sprintf("%s is a %s step without a url", field, step.Type),
+					})
+				}
+			default:
+				issues = append(issues, Issue{
+					Severity: SeverityError,
+					Code:     "unknown-fallback",
+					Message:  fmt.Sprintf("%s has unknown type %q", field, step.Type),
+				})
+			}
+		}
+	}
+
+	return issues
+}
+
+// lintPattern reports a pattern, or "pattern" fallback step, without an
+// expression or a url
+func lintPattern(field string, pattern Pattern) []Issue {
+	var issues []Issue
+	if strings.TrimSpace(pattern.Match) == "" {
+		issues = append(issues, Issue{
+			Severity: SeverityError,
+			Code:     "empty-match",
+			Message:  fmt.Sprintf("%s has no match expression", field),
+		})
+	}
+	if strings.TrimSpace(pattern.URL) == "" {
+		issues = append(issues, Issue{
+			Severity: SeverityError,
+			Code:     "empty-url",
+			Message:  fmt.Sprintf("%s has no url", field),
+		})
+	}
+	return issues
+}
+
+// lintTemplates reports templates that do not compile
+func lintTemplates(config *CommandConfig) []Issue {
+	var issues []Issue
+
+	registry := NewCommandRegistry(config)
+	for _, err := range registry.errs {
+		// Flags are reported by lintFlags
+		var flagErr *flagDeclarationError
+		if errors.As(err, &flagErr) {
+			continue
+		}
+		issue := Issue{
+			Severity: SeverityError,
+			Code:     "template",
+			Message:  err.Error(),
+		}
+		var templateErr *TemplateError
+		if errors.As(err, &templateErr) {
+			issue.Command = templateErr.Command
+			issue.Subcommand = templateErr.Subcommand
+			issue.Message = templateErr.Field + ": " + templateErr.Err.Error()
+		}
+		issues = append(issues, issue)
+	}
+
+	return issues
+}
+
+// joinPath appends a subcommand name to a space separated path
+func joinPath(parent, name string) string {
+	if parent == "" {
+		return name
+	}
+	return parent + " " + name
+}
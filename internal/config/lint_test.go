@@ -0,0 +1,208 @@
+package config
+
+import (
+	"testing"
+)
+
+// lintCodes returns the codes of the issues Lint finds, in order
+func lintCodes(config *CommandConfig) []string {
+	var codes []string
+	for _, issue := range Lint(config) {
+		codes = append(codes, issue.Code)
+	}
+	return codes
+}
+
+func TestLint_CleanConfig(t *testing.T) {
+	config := &CommandConfig{
+		Commands: []Command{
+			{Name: "google", Aliases: []string{"g"}, URL: "https://google.com/search?q={{.Query}}", Default: true},
+			{Name: "pulls", URL: "https://github.com/{{.Args.owner}}/pulls", RequiresQuery: true, Params: []Param{{Name: "owner"}}},
+			{
+				Name: "github",
+				URL:  "https://github.com",
+				Subcommands: []Subcommand{
+					{Name: "pr", Aliases: []string{"pull"}, URL: "https://github.com/pulls?q={{.Query}}"},
+					{Name: "issues", URL: "https://github.com/issues?q={{.Query}}"},
+				},
+			},
+		},
+	}
+
+	if issues := Lint(config); len(issues) != 0 {
+		t.Errorf("Expected no issues, got %+v", issues)
+	}
+}
+
+func TestLint(t *testing.T) {
+	tests := []struct {
+		name     string
+		config   *CommandConfig
+		expected []string
+	}{
+		{
+			name: "alias collides with command name",
+			config: &CommandConfig{Commands: []Command{
+				{Name: "gh", URL: "https://github.com"},
+				{Name: "github", Aliases: []string{"gh"}, URL: "https://github.com"},
+			}},
+			expected: []string{"alias-collision"},
+		},
+		{
+			name: "alias collides with another alias",
+			config: &CommandConfig{Commands: []Command{
+				{Name: "google", Aliases: []string{"g"}, URL: "https://google.com"},
+				{Name: "gitlab", Aliases: []string{"g"}, URL: "https://gitlab.com"},
+			}},
+			expected: []string{"alias-collision"},
+		},
+		{
+			name: "flags with bad names, types, values and defaults",
+			config: &CommandConfig{Commands: []Command{
+				{Name: "logs", URL: "https://logs.example.com/?q={{.Query}}", Flags: []Flag{
+					{Name: "env", Values: []string{"prod", "dev"}, Default: "staging"},
+					{Name: "-since", Type: FlagDuration},
+					{Name: "ENV"},
+					{Name: "limit", Type: "number"},
+					{Name: "level", Type: FlagInt, Values: []string{"1", "high"}},
+				}},
+			}},
+			expected: []string{"invalid-flag", "invalid-flag", "invalid-flag", "invalid-flag", "invalid-flag"},
+		},
+		{
+			name:     "abbreviations with unknown mode",
+			config:   &CommandConfig{Abbreviations: &AbbreviationConfig{Mode: "prefix"}},
+			expected: []string{"unknown-mode"},
+		},
+		{
+			name: "navigation with unknown mode and scheme",
+			config: &CommandConfig{Navigation: &NavigationConfig{
+				Mode:   "always",
+				Scheme: "https://",
+			}},
+			expected: []string{"unknown-mode", "invalid-scheme"},
+		},
+		{
+			name: "pattern without match or url",
+			config: &CommandConfig{Patterns: []Pattern{
+				{Name: "jira-key", URL: "https://jira.example.com/browse/{{.Query}}"},
+				{Name: "pr", Match: `#\d+`},
+			}},
+			expected: []string{"empty-match", "empty-url"},
+		},
+		{
+			name: "pattern fallback step without match or url",
+			config: &CommandConfig{Fallback: &FallbackConfig{Chain: []FallbackStep{
+				{Type: FallbackPattern, URL: "https://jira.example.com/browse/{{.Query}}"},
+				{Type: FallbackPattern, Pattern: `#\d+`},
+			}}},
+			expected: []string{"empty-match", "empty-url"},
+		},
+		{
+			name: "duplicate command name",
+			config: &CommandConfig{Commands: []Command{
+				{Name: "google", URL: "https://google.com"},
+				{Name: "Google", URL: "https://google.de"},
+			}},
+			expected: []string{"duplicate-name"},
+		},
+		{
+			name: "multiple defaults",
+			config: &CommandConfig{Commands: []Command{
+				{Name: "google", URL: "https://google.com", Default: true},
+				{Name: "bing", URL: "https://bing.com", Default: true},
+			}},
+			expected: []string{"multiple-defaults"},
+		},
+		{
+			name: "empty urls",
+			config: &CommandConfig{Commands: []Command{
+				{Name: "blank", URL: " ", Subcommands: []Subcommand{{Name: "sub"}}},
+			}},
+			expected: []string{"empty-url", "empty-url"},
+		},
+		{
+			name: "requiresQuery without query",
+			config: &CommandConfig{Commands: []Command{
+				{Name: "home", URL: "https://example.com/", RequiresQuery: true},
+			}},
+			expected: []string{"query-ignored"},
+		},
+		{
+			name: "duplicate subcommand alias and unreachable subcommand",
+			config: &CommandConfig{Commands: []Command{
+				{
+					Name: "github",
+					URL:  "https://github.com",
+					Subcommands: []Subcommand{
+						{Name: "pr", URL: "https://github.com/pulls"},
+						{Name: "pulls", Aliases: []string{"pr"}, URL: "https://github.com/pulls"},
+					},
+				},
+			}},
+			expected: []string{"duplicate-subcommand-alias", "unreachable-subcommand"},
+		},
+		{
+			name: "nested subcommands are checked",
+			config: &CommandConfig{Commands: []Command{
+				{
+					Name: "dd",
+					URL:  "https://datadog.com",
+					Subcommands: []Subcommand{
+						{Name: "logs", URL: "https://datadog.com/logs", Subcommands: []Subcommand{
+							{Name: "prod", URL: ""},
+						}},
+					},
+				},
+			}},
+			expected: []string{"empty-url"},
+		},
+		{
+			name: "invalid settings",
+			config: &CommandConfig{
+				Commands:   []Command{{Name: "google", URL: "https://google.com"}},
+				DidYouMean: &DidYouMeanConfig{Mode: "guess"},
+				Fallback:   &FallbackConfig{Chain: []FallbackStep{{Type: FallbackURL}, {Type: "magic"}}},
+			},
+			expected: []string{"unknown-mode", "empty-url", "unknown-fallback"},
+		},
+		{
+			name: "template errors",
+			config: &CommandConfig{Commands: []Command{
+				{Name: "broken", URL: "https://example.com/{{.Query"},
+			}},
+			expected: []string{"template"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			codes := lintCodes(tt.config)
+			if len(codes) != len(tt.expected) {
+				t.Fatalf("Expected issues %v, got %v", tt.expected, codes)
+			}
+			for i := range codes {
+				if codes[i] != tt.expected[i] {
+					t.Errorf("Expected issues %v, got %v", tt.expected, codes)
+					break
+				}
+			}
+		})
+	}
+}
+
+func TestLint_TemplateErrorLocation(t *testing.T) {
+	config := &CommandConfig{Commands: []Command{
+		{Name: "gh", URL: "https://github.com", Subcommands: []Subcommand{
+			{Name: "pr", URL: "https://github.com/{{.Nope}}"},
+		}},
+	}}
+
+	issues := Lint(config)
+	if len(issues) != 1 {
+		t.Fatalf("Expected 1 issue, got %+v", issues)
+	}
+	if issues[0].Command != "gh" || issues[0].Subcommand != "pr" || issues[0].Severity != SeverityError {
+		t.Errorf("Expected template error on gh pr, got %+v", issues[0])
+	}
+}
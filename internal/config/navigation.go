@@ -0,0 +1,157 @@
+package config
+
+import (
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// NavigationConfig controls queries that are addresses rather than
+// searches, such as "https://grafana.internal/x", "localhost:3000/debug" or
+// "grafana.internal", which are redirected to directly. Only local and
+// allowlisted hosts are navigated to unless the mode is "any", so a shared
+// server does not redirect to arbitrary sites.
+type NavigationConfig struct {
+	// Mode is "on", "any" or "off". It defaults to "on" when Suffixes are
+	// listed and to "off" otherwise.
+	Mode string `json:"mode,omitempty" yaml:"mode,omitempty" toml:"mode,omitempty"`
+	// Scheme is added to addresses typed without one; defaults to "https"
+	Scheme string `json:"scheme,omitempty" yaml:"scheme,omitempty" toml:"scheme,omitempty"`
+	// LocalScheme replaces Scheme for localhost and IP addresses; defaults
+	// to "http"
+	LocalScheme string `json:"localScheme,omitempty" yaml:"localScheme,omitempty" toml:"localScheme,omitempty"`
+	// Suffixes lists domains, such as "internal" or "corp.example.com",
+	// whose hosts are navigated to
+	Suffixes []string `json:"suffixes,omitempty" yaml:"suffixes,omitempty" toml:"suffixes,omitempty"`
+}
+
+// Navigation modes: "on" navigates to localhost, loopback and private IP
+// addresses and allowlisted hosts, "any" to any URL, dotted host with a
+// port or IP address as well
+const (
+	NavigationOn  = "on"
+	NavigationAny = "any"
+	NavigationOff = "off"
+)
+
+// Default schemes for addresses typed without one
+const (
+	defaultNavigationScheme      = "https"
+	defaultNavigationLocalScheme = "http"
+)
+
+// NavigationURL returns the URL to go to when the query is an address:
+// a URL with a scheme, an address with a port, an IP address, localhost,
+// or a host under one of the configured suffixes. Unless the mode is
+// "any", other hosts than local and allowlisted ones are left to be
+// searched for, as are bare names like "node.js".
+func (r *CommandRegistry) NavigationURL(query string) (string, bool) {
+	settings := r.navigation
+	if settings.Mode == NavigationOff || query == "" || strings.ContainsAny(query, " \t\r\n") {
+		return "", false
+	}
+	open := settings.Mode == NavigationAny
+
+	if strings.Contains(query, "://") {
+		u, err := url.Parse(query)
+		if err != nil || u.Scheme == "" || u.Host == "" {
+			return "", false
+		}
+		host := strings.ToLower(u.Hostname())
+		if !open && !isLocalHost(host) && !settings.hasSuffix(host) {
+			return "", false
+		}
+		return query, true
+	}
+
+	hostport := query
+	if end := strings.IndexAny(query, "/?#"); end >= 0 {
+		hostport = query[:end]
+	}
+	host, port, ok := splitHostPort(hostport)
+	if !ok {
+		return "", false
+	}
+	host = strings.ToLower(host)
+
+	switch {
+	case isLocalHost(host), open && net.ParseIP(host) != nil:
+		return settings.LocalScheme + "://" + query, true
+	case net.ParseIP(host) != nil, !isHostname(host):
+		return "", false
+	case open && port != "" && strings.Contains(host, "."), settings.hasSuffix(host):
+		return settings.Scheme + "://" + query, true
+	}
+	return "", false
+}
+
+// isLocalHost reports whether host is localhost, a subdomain of it, or a
+// loopback or private IP address
+func isLocalHost(host string) bool {
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && (ip.IsLoopback() || ip.IsPrivate())
+}
+
+// hasSuffix reports whether host is one of the allowlisted domains or a
+// subdomain of one
+func (n NavigationConfig) hasSuffix(host string) bool {
+	for _, suffix := range n.Suffixes {
+		suffix = strings.ToLower(strings.Trim(suffix, "."))
+		if suffix == "" {
+			continue
+		}
+		if host == suffix || strings.HasSuffix(host, "."+suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+// splitHostPort splits an address typed without a scheme into host and
+// port, which may be empty. IPv6 addresses must be in brackets.
+func splitHostPort(hostport string) (host, port string, ok bool) {
+	switch {
+	case strings.HasPrefix(hostport, "[") && strings.HasSuffix(hostport, "]"):
+		host = hostport[1 : len(hostport)-1]
+	case strings.HasPrefix(hostport, "["), strings.Count(hostport, ":") == 1:
+		var err error
+		host, port, err = net.SplitHostPort(hostport)
+		if err != nil {
+			return "", "", false
+		}
+	case strings.Contains(hostport, ":"):
+		return "", "", false
+	default:
+		host = hostport
+	}
+
+	if port != "" {
+		n, err := strconv.Atoi(port)
+		if err != nil || n < 1 || n > 65535 {
+			return "", "", false
+		}
+	}
+	return host, port, host != ""
+}
+
+// isHostname reports whether host is made of valid DNS labels
+func isHostname(host string) bool {
+	if len(host) > 253 {
+		return false
+	}
+	for _, label := range strings.Split(host, ".") {
+		if label == "" || len(label) > 63 || label[0] == '-' || label[len(label)-1] == '-' {
+			return false
+		}
+		for _, c := range label {
+			if !(c >= 'a' && c <= 'z' || c >= '0' && c <= '9' || c == '-') {
+				return false
+			}
+		}
+	}
+	return true
+}
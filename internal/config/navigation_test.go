@@ -0,0 +1,108 @@
+package config
+
+import "testing"
+
+func TestNavigationURL(t *testing.T) {
+	registry := NewCommandRegistry(&CommandConfig{Navigation: &NavigationConfig{
+		Suffixes: []string{".internal", "corp.example.com"},
+	}})
+
+	tests := []struct {
+		query    string
+		expected string
+	}{
+		{query: "https://grafana.internal/a?b=c", expected: "https://grafana.internal/a?b=c"},
+		{query: "ftp://files.corp.example.com", expected: "ftp://files.corp.example.com"},
+		{query: "http://localhost:3000", expected: "http://localhost:3000"},
+		{query: "localhost:3000/debug", expected: "http://localhost:3000/debug"},
+		{query: "localhost", expected: "http://localhost"},
+		{query: "app.localhost:8080", expected: "http://app.localhost:8080"},
+		{query: "192.168.1.10", expected: "http://192.168.1.10"},
+		{query: "10.0.0.1:9090/metrics", expected: "http://10.0.0.1:9090/metrics"},
+		{query: "[::1]:8080", expected: "http://[::1]:8080"},
+		{query: "grafana.internal", expected: "https://grafana.internal"},
+		{query: "Grafana.Internal/d/abc", expected: "https://Grafana.Internal/d/abc"},
+		{query: "wiki.corp.example.com", expected: "https://wiki.corp.example.com"},
+		{query: "corp.example.com?x=1", expected: "https://corp.example.com?x=1"},
+		{query: "grafana.internal:8443", expected: "https://grafana.internal:8443"},
+		// Hosts that are not local or allowlisted are searched for
+		{query: "https://example.com/a?b=c"},
+		{query: "https://grafana.internal.evil.com"},
+		{query: "example.com:8443"},
+		{query: "8.8.8.8"},
+		{query: "node.js"},
+		{query: "example.com"},
+		{query: "notinternal"},
+		// A port alone does not make a single-label name an address
+		{query: "grafana:3000"},
+		// Neither are words with a colon, bad ports or several tokens
+		{query: "chapter:3"},
+		{query: "example.com:99999"},
+		{query: "example.com:http"},
+		{query: "grafana.internal dashboards"},
+		{query: "::1"},
+		{query: "https://"},
+		{query: "-bad-.internal"},
+		{query: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			target, ok := registry.NavigationURL(tt.query)
+			if ok != (tt.expected != "") || target != tt.expected {
+				t.Errorf("NavigationURL(%q) = %q, %v, expected %q", tt.query, target, ok, tt.expected)
+			}
+		})
+	}
+}
+
+func TestNavigationURL_Any(t *testing.T) {
+	registry := NewCommandRegistry(&CommandConfig{Navigation: &NavigationConfig{Mode: NavigationAny}})
+
+	tests := []struct {
+		query    string
+		expected string
+	}{
+		{query: "https://example.com/a?b=c", expected: "https://example.com/a?b=c"},
+		{query: "example.com:8443", expected: "https://example.com:8443"},
+		{query: "8.8.8.8", expected: "http://8.8.8.8"},
+		// A port alone still does not make a single-label name an address
+		{query: "grafana:3000"},
+		{query: "example.com"},
+	}
+
+	for _, tt := range tests {
+		target, ok := registry.NavigationURL(tt.query)
+		if ok != (tt.expected != "") || target != tt.expected {
+			t.Errorf("NavigationURL(%q) = %q, %v, expected %q", tt.query, target, ok, tt.expected)
+		}
+	}
+}
+
+func TestNavigationURL_Settings(t *testing.T) {
+	// Without configuration nothing is navigated to
+	registry := NewCommandRegistry(&CommandConfig{})
+	for _, query := range []string{"https://example.com", "localhost:3000"} {
+		if target, ok := registry.NavigationURL(query); ok {
+			t.Errorf("Expected navigation off by default, got %q for %q", target, query)
+		}
+	}
+
+	registry = NewCommandRegistry(&CommandConfig{Navigation: &NavigationConfig{Mode: NavigationOn}})
+	if target, _ := registry.NavigationURL("localhost:8443"); target != "http://localhost:8443" {
+		t.Errorf("Expected http for local addresses by default, got %q", target)
+	}
+
+	registry = NewCommandRegistry(&CommandConfig{Navigation: &NavigationConfig{Scheme: "http", LocalScheme: "https", Suffixes: []string{"internal"}}})
+	if target, _ := registry.NavigationURL("grafana.internal"); target != "http://grafana.internal" {
+		t.Errorf("Expected the configured scheme, got %q", target)
+	}
+	if target, _ := registry.NavigationURL("localhost:8443"); target != "https://localhost:8443" {
+		t.Errorf("Expected the configured local scheme, got %q", target)
+	}
+
+	registry = NewCommandRegistry(&CommandConfig{Navigation: &NavigationConfig{Mode: NavigationOff, Suffixes: []string{"internal"}}})
+	if target, ok := registry.NavigationURL("grafana.internal"); ok {
+		t.Errorf("Expected no navigation when off, got %q", target)
+	}
+}
@@ -0,0 +1,109 @@
+package config
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"text/template"
+)
+
+// Pattern sends queries that match a regular expression to a URL, for
+// things typed without a keyword such as ticket keys or PR numbers
+type Pattern struct {
+	// Name labels the pattern in analytics and on the help page; defaults
+	// to the expression itself
+	Name        string `json:"name,omitempty" yaml:"name,omitempty" toml:"name,omitempty"`
+	Description string `json:"description,omitempty" yaml:"description,omitempty" toml:"description,omitempty"`
+	// Match is a regular expression that must match the whole query. Its
+	// named groups are available to URL as {{.Args.name}}.
+	Match string `json:"match" yaml:"match" toml:"match"`
+	URL   string `json:"url" yaml:"url" toml:"url"`
+	// Encoding applies to the query and the captured groups, see Encode
+	Encoding string    `json:"encoding,omitempty" yaml:"encoding,omitempty" toml:"encoding,omitempty"`
+	Examples []Example `json:"examples,omitempty" yaml:"examples,omitempty" toml:"examples,omitempty"`
+
+	re          *regexp.Regexp
+	urlTemplate *template.Template
+}
+
+// Label returns the name identifying the pattern
+func (p Pattern) Label() string {
+	if p.Name != "" {
+		return p.Name
+	}
+	return p.Match
+}
+
+// Expand builds the pattern's target URL for a query it matched, with the
+// named groups of the match as arguments
+func (p Pattern) Expand(raw string, groups map[string]string) (string, error) {
+	data, err := NewTemplateData(raw, nil, nil, p.Encoding)
+	if err != nil {
+		return "", err
+	}
+	for name, value := range groups {
+		// The encoding is known to be valid at this point
+		data.Args[name], _ = Encode(p.Encoding, value)
+	}
+	if p.urlTemplate == nil {
+		return RenderURL(p.URL, data)
+	}
+	return executeURLTemplate(p.urlTemplate, data)
+}
+
+// compilePatterns compiles the expressions and templates of the patterns
+func (r *CommandRegistry) compilePatterns() {
+	for i := range r.patterns {
+		field := "patterns[" + strconv.Itoa(i) + "]"
+		r.compilePattern(&r.patterns[i], field+".match", field+".url")
+	}
+}
+
+// compilePattern compiles the expression and template of a pattern. A
+// pattern whose expression does not compile never matches.
+func (r *CommandRegistry) compilePattern(pattern *Pattern, matchField, urlField string) {
+	re, err := regexp.Compile(`^(?:` + pattern.Match + `)$`)
+	if err != nil {
+		r.errs = append(r.errs, fmt.Errorf("%s %q: %w", matchField, pattern.Match, err))
+	} else {
+		pattern.re = re
+	}
+
+	pattern.urlTemplate = r.compileTemplate(pattern.URL, nil, pattern.Encoding, "", "", urlField)
+}
+
+// MatchPattern returns the first pattern matching the whole query and the
+// values of its named groups, or nil when none matches
+func (r *CommandRegistry) MatchPattern(query string) (*Pattern, map[string]string) {
+	for i := range r.patterns {
+		if groups, matched := r.patterns[i].match(query); matched {
+			return &r.patterns[i], groups
+		}
+	}
+	return nil, nil
+}
+
+// match reports whether the pattern matches the whole query and returns
+// the values of its named groups
+func (p *Pattern) match(query string) (map[string]string, bool) {
+	if p.re == nil {
+		return nil, false
+	}
+	match := p.re.FindStringSubmatch(query)
+	if match == nil {
+		return nil, false
+	}
+
+	groups := make(map[string]string)
+	for i, name := range p.re.SubexpNames() {
+		if name != "" {
+			groups[name] = match[i]
+		}
+	}
+	return groups, true
+}
+
+// ListPatterns returns the patterns in the order they are tried
+func (r *CommandRegistry) ListPatterns() []Pattern {
+	return r.patterns
+}
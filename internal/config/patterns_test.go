@@ -0,0 +1,71 @@
+package config
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestMatchPattern(t *testing.T) {
+	registry := NewCommandRegistry(&CommandConfig{Patterns: []Pattern{
+		{Name: "jira-key", Match: `(?P<key>[A-Z]+-\d+)`, URL: "https://jira.example.com/browse/{{.Args.key}}"},
+		{Name: "pr", Match: `#(?P<number>\d+)(?: (?P<note>.*))?`, URL: "https://github.com/acme/app/pull/{{.Args.number}}?note={{.Args.note}}"},
+	}})
+	if err := registry.Err(); err != nil {
+		t.Fatalf("Expected valid patterns, got %v", err)
+	}
+
+	tests := []struct {
+		query    string
+		pattern  string
+		groups   map[string]string
+		expected string
+	}{
+		{query: "PROJ-1234", pattern: "jira-key", groups: map[string]string{"key": "PROJ-1234"}, expected: "https://jira.example.com/browse/PROJ-1234"},
+		{query: "#4521 flaky test", pattern: "pr", groups: map[string]string{"number": "4521", "note": "flaky test"}, expected: "https://github.com/acme/app/pull/4521?note=flaky+test"},
+		{query: "#4521", pattern: "pr", groups: map[string]string{"number": "4521", "note": ""}, expected: "https://github.com/acme/app/pull/4521?note="},
+		// The expression must match the whole query
+		{query: "see PROJ-1234"},
+		{query: "PROJ-1234 now"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			pattern, groups := registry.MatchPattern(tt.query)
+			if tt.pattern == "" {
+				if pattern != nil {
+					t.Fatalf("Expected no match, got %q", pattern.Label())
+				}
+				return
+			}
+			if pattern == nil || pattern.Label() != tt.pattern {
+				t.Fatalf("Expected pattern %q, got %+v", tt.pattern, pattern)
+			}
+			if !reflect.DeepEqual(groups, tt.groups) {
+				t.Errorf("Expected groups %v, got %v", tt.groups, groups)
+			}
+			url, err := pattern.Expand(tt.query, groups)
+			if err != nil {
+				t.Fatalf("Expand failed: %v", err)
+			}
+			if url != tt.expected {
+				t.Errorf("Expected %q, got %q", tt.expected, url)
+			}
+		})
+	}
+}
+
+func TestMatchPattern_Invalid(t *testing.T) {
+	registry := NewCommandRegistry(&CommandConfig{Patterns: []Pattern{
+		{Match: `(?P<key>[A-Z`, URL: "https://example.com/{{.Args.key}}"},
+		{Match: `INC\d+`, URL: "https://example.com/{{.Missing}}"},
+	}})
+
+	err := registry.Err()
+	if err == nil || !strings.Contains(err.Error(), "patterns[0].match") || !strings.Contains(err.Error(), "patterns[1].url") {
+		t.Fatalf("Expected errors for both patterns, got %v", err)
+	}
+	if pattern, _ := registry.MatchPattern("ABC"); pattern != nil {
+		t.Errorf("Expected an invalid pattern never to match, got %+v", pattern)
+	}
+}
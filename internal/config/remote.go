@@ -0,0 +1,321 @@
+package config
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// maxRemoteConfigSize bounds how much of a remote config is read
+const maxRemoteConfigSize = 10 << 20
+
+// RemoteCacheDir is where the last good copy of each remote config is kept.
+// An empty string disables the disk cache.
+var RemoteCacheDir = defaultRemoteCacheDir()
+
+// remoteClient fetches remote configs
+var remoteClient = &http.Client{Timeout: 10 * time.Second}
+
+// remoteSources holds one RemoteSource per URL so validators carry over
+// between loads
+var remoteSources = struct {
+	sync.Mutex
+	byURL map[string]*RemoteSource
+}{byURL: make(map[string]*RemoteSource)}
+
+func defaultRemoteCacheDir() string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "gopherlol", "remote")
+}
+
+// IsRemote reports whether a config source is an http(s) URL rather than a path
+func IsRemote(source string) bool {
+	return strings.HasPrefix(source, "http://") || strings.HasPrefix(source, "https://")
+}
+
+// StaleError reports that a remote config could not be fetched, or was
+// invalid, and its last good copy was used instead
+type StaleError struct {
+	URL string
+	Err error
+}
+
+func (e *StaleError) Error() string {
+	return fmt.Sprintf("using cached copy of %s: %v", e.URL, e.Err)
+}
+
+func (e *StaleError) Unwrap() error {
+	return e.Err
+}
+
+// IsStale reports whether err consists only of StaleErrors, meaning the
+// config was loaded, partly from cached copies
+func IsStale(err error) bool {
+	if err == nil {
+		return false
+	}
+	errs := []error{err}
+	if joined, ok := err.(interface{ Unwrap() []error }); ok {
+		errs = joined.Unwrap()
+	}
+	for _, err := range errs {
+		var staleErr *StaleError
+		if !errors.As(err, &staleErr) {
+			return false
+		}
+	}
+	return true
+}
+
+// RemoteSource fetches a config over HTTP. Requests are conditional on the
+// ETag and Last-Modified of the last good copy, which is also kept on disk
+// so an unreachable server does not stop gopherlol from starting.
+type RemoteSource struct {
+	URL string
+
+	mu           sync.Mutex
+	cacheLoaded  bool
+	data         []byte
+	etag         string
+	lastModified string
+}
+
+// remoteCache is the on-disk form of a RemoteSource's last good copy
+type remoteCache struct {
+	URL          string `json:"url"`
+	ETag         string `json:"etag,omitempty"`
+	LastModified string `json:"lastModified,omitempty"`
+	Body         string `json:"body"`
+}
+
+// Remote returns the shared RemoteSource for a URL
+func Remote(rawURL string) *RemoteSource {
+	remoteSources.Lock()
+	defer remoteSources.Unlock()
+
+	source, exists := remoteSources.byURL[rawURL]
+	if !exists {
+		source = &RemoteSource{URL: rawURL}
+		remoteSources.byURL[rawURL] = source
+	}
+	return source
+}
+
+// Fetch returns the current config, downloading it only when it changed.
+// A new copy is accepted when validate approves it. When the server cannot
+// be reached, answers with an error, or serves a copy validate rejects, the
+// last good copy is returned along with a *StaleError; without one, Fetch
+// fails.
+func (s *RemoteSource) Fetch(validate func([]byte) error) ([]byte, error) {
+	data, _, err := s.fetch(validate)
+	return data, err
+}
+
+// Poll fetches the config and reports whether it differs from the copy
+// held before
+func (s *RemoteSource) Poll(validate func([]byte) error) (bool, error) {
+	_, changed, err := s.fetch(validate)
+	return changed, err
+}
+
+func (s *RemoteSource) fetch(validate func([]byte) error) ([]byte, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.cacheLoaded {
+		s.readCache()
+		s.cacheLoaded = true
+	}
+
+	req, err := http.NewRequest(http.MethodGet, s.URL, nil)
+	if err != nil {
+		return nil, false, fmt.Errorf("invalid config URL: %w", err)
+	}
+	if s.data != nil {
+		if s.etag != "" {
+			req.Header.Set("If-None-Match", s.etag)
+		}
+		if s.lastModified != "" {
+			req.Header.Set("If-Modified-Since", s.lastModified)
+		}
+	}
+
+	resp, err := remoteClient.Do(req)
+	if err != nil {
+		return s.stale(err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	switch {
+	case resp.StatusCode == http.StatusNotModified && s.data != nil:
+		return s.data, false, nil
+	case resp.StatusCode != http.StatusOK:
+		return s.stale(fmt.Errorf("unexpected status %s", resp.Status))
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxRemoteConfigSize))
+	if err != nil {
+		return s.stale(err)
+	}
+	if validate != nil {
+		if err := validate(body); err != nil {
+			return s.stale(err)
+		}
+	}
+
+	changed := !bytes.Equal(body, s.data)
+	s.data = body
+	s.etag = resp.Header.Get("ETag")
+	s.lastModified = resp.Header.Get("Last-Modified")
+	s.writeCache()
+
+	return s.data, changed, nil
+}
+
+// stale falls back to the last good copy
+func (s *RemoteSource) stale(err error) ([]byte, bool, error) {
+	if s.data == nil {
+		return nil, false, fmt.Errorf("failed to fetch %s: %w", s.URL, err)
+	}
+	return s.data, false, &StaleError{URL: s.URL, Err: err}
+}
+
+// cachePath is the file the last good copy is kept in
+func (s *RemoteSource) cachePath() string {
+	if RemoteCacheDir == "" {
+		return ""
+	}
+	sum := sha256.Sum256([]byte(s.URL))
+	return filepath.Join(RemoteCacheDir, hex.EncodeToString(sum[:8])+".json")
+}
+
+// readCache restores the last good copy from disk, if there is one
+func (s *RemoteSource) readCache() {
+	cachePath := s.cachePath()
+	if cachePath == "" {
+		return
+	}
+
+	data, err := os.ReadFile(cachePath)
+	if err != nil {
+		return
+	}
+	var cache remoteCache
+	if err := json.Unmarshal(data, &cache); err != nil || cache.URL != s.URL {
+		return
+	}
+
+	s.data = []byte(cache.Body)
+	s.etag = cache.ETag
+	s.lastModified = cache.LastModified
+}
+
+// writeCache saves the current copy to disk. The cache only helps when the
+// server is down, so failing to write it is not an error.
+func (s *RemoteSource) writeCache() {
+	cachePath := s.cachePath()
+	if cachePath == "" {
+		return
+	}
+
+	data, err := json.Marshal(remoteCache{URL: s.URL, ETag: s.etag, LastModified: s.lastModified, Body: string(s.data)})
+	if err != nil {
+		return
+	}
+	if err := os.MkdirAll(filepath.Dir(cachePath), 0o755); err != nil {
+		return
+	}
+
+	// Write then rename so a crash never leaves a truncated cache
+	tmp := cachePath + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return
+	}
+	_ = os.Rename(tmp, cachePath)
+}
+
+// readSource reads a config from a path or URL. Remote configs must pass
+// validRemoteConfig to replace the cached copy.
+func readSource(source, format string) ([]byte, error) {
+	if !IsRemote(source) {
+		data, err := os.ReadFile(source)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read config file: %w", err)
+		}
+		return data, nil
+	}
+
+	return Remote(source).Fetch(validRemoteConfig(format))
+}
+
+// PollRemote checks a remote config in format, or in the format of its
+// extension when format is empty, for changes since it was last fetched
+func PollRemote(source, format string) (bool, error) {
+	if format == "" {
+		format = DetectFormat(source)
+	}
+	return Remote(source).Poll(validRemoteConfig(format))
+}
+
+// validRemoteConfig returns a validator that accepts a remote config only
+// if it decodes in format and builds a valid registry, so a broken copy
+// never replaces the last good one
+func validRemoteConfig(format string) func([]byte) error {
+	return func(data []byte) error {
+		config, err := DecodeConfig(data, format)
+		if err != nil {
+			return err
+		}
+		if _, err := NewValidatedCommandRegistry(config); err != nil {
+			return fmt.Errorf("invalid command configuration: %w", err)
+		}
+		return nil
+	}
+}
+
+// resolveInclude resolves an include entry relative to the file or URL
+// that lists it
+func resolveInclude(base, include string) string {
+	if IsRemote(include) || filepath.IsAbs(include) && !IsRemote(base) {
+		return include
+	}
+
+	if IsRemote(base) {
+		baseURL, err := url.Parse(base)
+		if err != nil {
+			return include
+		}
+		ref, err := url.Parse(include)
+		if err != nil {
+			return include
+		}
+		return baseURL.ResolveReference(ref).String()
+	}
+
+	return filepath.Join(filepath.Dir(base), include)
+}
+
+// sourceExt returns the extension of a path, or of a URL's path
+func sourceExt(source string) string {
+	if IsRemote(source) {
+		if u, err := url.Parse(source); err == nil {
+			return path.Ext(u.Path)
+		}
+	}
+	return filepath.Ext(source)
+}
@@ -0,0 +1,213 @@
+package config
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+// useRemoteCache points the remote cache at a temporary directory and
+// forgets every RemoteSource, as if the process had restarted
+func useRemoteCache(t *testing.T, dir string) {
+	t.Helper()
+	previous := RemoteCacheDir
+	RemoteCacheDir = dir
+	remoteSources.Lock()
+	remoteSources.byURL = make(map[string]*RemoteSource)
+	remoteSources.Unlock()
+	t.Cleanup(func() { RemoteCacheDir = previous })
+}
+
+// configServer serves body with an ETag and counts full and conditional
+// responses
+type configServer struct {
+	body        atomic.Value
+	full        atomic.Int32
+	notModified atomic.Int32
+}
+
+func (s *configServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body := s.body.Load().(string)
+	etag := `"` + body[len(body)-8:] + `"`
+	if r.Header.Get("If-None-Match") == etag {
+		s.notModified.Add(1)
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	s.full.Add(1)
+	w.Header().Set("ETag", etag)
+	_, _ = w.Write([]byte(body))
+}
+
+const (
+	remoteConfigV1 = `{"commands": [{"name": "google", "url": "https://www.google.com/?q={{.Query}}"}]} // v1.....`
+	remoteConfigV2 = `{"commands": [{"name": "google", "url": "https://www.google.com/?q={{.Query}}"}, {"name": "ddg", "url": "https://duckduckgo.com/?q={{.Query}}"}]} // v2.....`
+)
+
+func TestLoadConfig_Remote(t *testing.T) {
+	useRemoteCache(t, t.TempDir())
+
+	handler := &configServer{}
+	handler.body.Store(remoteConfigV1)
+	server := httptest.NewServer(handler)
+	defer server.Close()
+	source := server.URL + "/commands.json"
+
+	config, err := LoadConfig(source)
+	if err != nil {
+		t.Fatalf("Failed to load remote config: %v", err)
+	}
+	if len(config.Commands) != 1 {
+		t.Fatalf("Expected 1 command, got %d", len(config.Commands))
+	}
+
+	changed, err := PollRemote(source, "")
+	if err != nil || changed {
+		t.Errorf("Expected an unchanged config, got changed=%v err=%v", changed, err)
+	}
+	if handler.full.Load() != 1 || handler.notModified.Load() != 1 {
+		t.Errorf("Expected the poll to revalidate with If-None-Match, got %d full and %d not-modified responses", handler.full.Load(), handler.notModified.Load())
+	}
+
+	handler.body.Store(remoteConfigV2)
+	changed, err = PollRemote(source, "")
+	if err != nil || !changed {
+		t.Errorf("Expected a changed config, got changed=%v err=%v", changed, err)
+	}
+	config, err = LoadConfig(source)
+	if err != nil || len(config.Commands) != 2 {
+		t.Errorf("Expected the new config, got %v (%v)", config, err)
+	}
+}
+
+func TestLoadConfig_RemoteLastModified(t *testing.T) {
+	useRemoteCache(t, t.TempDir())
+
+	var conditional atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		const modified = "Mon, 02 Jan 2006 15:04:05 GMT"
+		if r.Header.Get("If-Modified-Since") == modified {
+			conditional.Add(1)
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("Last-Modified", modified)
+		_, _ = w.Write([]byte("commands:\n  - name: google\n    url: https://www.google.com/\n"))
+	}))
+	defer server.Close()
+	source := server.URL + "/commands.yaml?token=abc"
+
+	for i := 0; i < 2; i++ {
+		config, err := LoadConfig(source)
+		if err != nil || len(config.Commands) != 1 {
+			t.Fatalf("Expected the YAML config, got %v (%v)", config, err)
+		}
+	}
+	if conditional.Load() != 1 {
+		t.Errorf("Expected the second load to send If-Modified-Since")
+	}
+}
+
+func TestLoadConfig_RemoteFallsBackToCache(t *testing.T) {
+	cacheDir := t.TempDir()
+	useRemoteCache(t, cacheDir)
+
+	handler := &configServer{}
+	handler.body.Store(remoteConfigV1)
+	server := httptest.NewServer(handler)
+	source := server.URL + "/commands.json"
+
+	if _, err := LoadConfig(source); err != nil {
+		t.Fatalf("Failed to load remote config: %v", err)
+	}
+
+	// A broken update keeps the last good copy
+	handler.body.Store(`{"commands": [ // broken`)
+	config, err := LoadConfig(source)
+	var staleErr *StaleError
+	if !errors.As(err, &staleErr) || !IsStale(err) || len(config.Commands) != 1 {
+		t.Errorf("Expected the cached config with a StaleError, got %v (%v)", config, err)
+	}
+
+	// So does one that decodes but does not build a valid registry
+	handler.body.Store(`{"commands": [{"name": "google", "url": "https://www.google.com/?q={{.Query"}, {"name": "ddg", "url": "https://duckduckgo.com/?q={{.Query}}"}]} // invalid`)
+	if changed, err := PollRemote(source, ""); changed || !IsStale(err) {
+		t.Errorf("Expected an invalid update to be rejected, got changed=%v err=%v", changed, err)
+	}
+
+	// After a restart with the server gone, the copy on disk is used
+	server.Close()
+	useRemoteCache(t, cacheDir)
+	config, err = LoadConfig(source)
+	if !IsStale(err) || config == nil || len(config.Commands) != 1 {
+		t.Errorf("Expected the disk cache with a StaleError, got %v (%v)", config, err)
+	}
+
+	// Without a cached copy there is nothing to serve
+	useRemoteCache(t, t.TempDir())
+	if _, err := LoadConfig(source); err == nil || IsStale(err) {
+		t.Errorf("Expected a fetch error without a cache, got %v", err)
+	}
+}
+
+func TestPollRemote_Format(t *testing.T) {
+	useRemoteCache(t, t.TempDir())
+
+	handler := &configServer{}
+	handler.body.Store("commands:\n  - name: google\n    url: https://www.google.com/?q={{.Query}}\n# v1.....")
+	server := httptest.NewServer(handler)
+	defer server.Close()
+	// Nothing in the URL says this is YAML
+	source := server.URL + "/config"
+
+	if _, err := LoadConfigFormat(source, FormatYAML); err != nil {
+		t.Fatalf("Failed to load remote config: %v", err)
+	}
+
+	handler.body.Store("commands:\n  - name: google\n    url: https://www.google.com/?q={{.Query}}\n  - name: ddg\n    url: https://duckduckgo.com/?q={{.Query}}\n# v2.....")
+	if changed, err := PollRemote(source, FormatYAML); err != nil || !changed {
+		t.Errorf("Expected the YAML update to be detected, got changed=%v err=%v", changed, err)
+	}
+}
+
+func TestLoadConfigFiles_RemoteIncludes(t *testing.T) {
+	useRemoteCache(t, "")
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/team/commands.json":
+			_, _ = w.Write([]byte(`{"include": ["search.toml"], "commands": [{"name": "wiki", "url": "https://wiki.internal"}]}`))
+		case "/team/search.toml":
+			_, _ = w.Write([]byte("[[commands]]\nname = \"ddg\"\nurl = \"https://duckduckgo.com/?q={{.Query}}\"\n"))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	config, watched, err := LoadConfigFiles(server.URL+"/team/commands.json", FormatJSON)
+	if err != nil {
+		t.Fatalf("Failed to load: %v", err)
+	}
+	if names := commandNames(config); len(names) != 2 || names[1] != "ddg" {
+		t.Errorf("Expected the included remote command, got %v", names)
+	}
+	if len(watched) != 2 || !strings.HasSuffix(watched[1], "/team/search.toml") {
+		t.Errorf("Expected both URLs to be watched, got %v", watched)
+	}
+	if origin := config.Commands[1].Origin; origin.String() != server.URL+"/team/search.toml:1" {
+		t.Errorf("Expected the URL as origin, got %s", origin)
+	}
+}
+
+func TestDetectFormat_URL(t *testing.T) {
+	if format := DetectFormat("https://config.example.com/commands.toml?ref=main"); format != FormatTOML {
+		t.Errorf("Expected toml, got %q", format)
+	}
+	if format := DetectFormat("https://config.example.com/commands"); format != FormatJSON {
+		t.Errorf("Expected json, got %q", format)
+	}
+}
@@ -0,0 +1,102 @@
+package config
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Suggestion represents a single query completion offered while typing
+type Suggestion struct {
+	Completion  string
+	Description string
+}
+
+// Suggest returns completions for a partially typed query.
+// Command names are completed first, followed by the subcommands of a
+// command or subcommand that has already been typed.
+func (r *CommandRegistry) Suggest(query string) []Suggestion {
+	query = strings.ToLower(query)
+
+	// Whitespace after the last word starts a new, empty one
+	parts := strings.Fields(query)
+	if len(parts) == 0 || strings.TrimRightFunc(query, unicode.IsSpace) != query {
+		parts = append(parts, "")
+	}
+
+	if len(parts) == 1 {
+		suggestions := r.suggestCommands(parts[0])
+		if r.FindCommand(parts[0]) != nil {
+			suggestions = append(suggestions, suggestSubcommands(parts[0], r.commandSubcommands(parts[0]), "")...)
+		}
+		return suggestions
+	}
+
+	if r.FindCommand(parts[0]) == nil {
+		return nil
+	}
+
+	// Every token between the command and the one being typed must name a subcommand
+	parents := parts[1 : len(parts)-1]
+	path := r.FindSubcommandPath(parts[0], parents)
+	if len(path) != len(parents) {
+		return nil
+	}
+
+	subMap := r.commandSubcommands(parts[0])
+	if len(path) > 0 {
+		subMap = r.children[path[len(path)-1]]
+	}
+
+	prefix := strings.Join(parts[:len(parts)-1], " ")
+	last := parts[len(parts)-1]
+	suggestions := suggestSubcommands(prefix, subMap, last)
+	if sub, exists := subMap.get(last); exists {
+		suggestions = append(suggestions, suggestSubcommands(prefix+" "+last, r.children[sub], "")...)
+	}
+
+	return suggestions
+}
+
+// suggestCommands completes command names, then aliases, matching prefix
+func (r *CommandRegistry) suggestCommands(prefix string) []Suggestion {
+	var suggestions []Suggestion
+
+	r.commands.walk(prefix, func(key string, cmd *Command) {
+		suggestions = append(suggestions, Suggestion{
+			Completion:  key,
+			Description: cmd.Description,
+		})
+	})
+
+	r.aliases.walk(prefix, func(key string, cmd *Command) {
+		if _, isName := r.commands.get(key); isName {
+			return
+		}
+		suggestions = append(suggestions, Suggestion{
+			Completion:  key,
+			Description: cmd.Description,
+		})
+	})
+
+	return suggestions
+}
+
+// suggestSubcommands completes the names and aliases in one level of the
+// subcommand tree, prepending the already typed command path
+func suggestSubcommands(path string, subMap *trie[*Subcommand], prefix string) []Suggestion {
+	// List canonical names before aliases so the main entries come first
+	var names, aliases []Suggestion
+	subMap.walk(prefix, func(key string, sub *Subcommand) {
+		suggestion := Suggestion{
+			Completion:  path + " " + key,
+			Description: sub.Description,
+		}
+		if strings.ToLower(sub.Name) == key {
+			names = append(names, suggestion)
+		} else {
+			aliases = append(aliases, suggestion)
+		}
+	})
+
+	return append(names, aliases...)
+}
@@ -0,0 +1,64 @@
+package config
+
+import "testing"
+
+func TestSuggest(t *testing.T) {
+	config := &CommandConfig{
+		Commands: []Command{
+			{Name: "google", Aliases: []string{"g"}, Description: "Search Google"},
+			{Name: "gmail", Aliases: []string{"mail"}, Description: "Gmail search"},
+			{
+				Name:        "github",
+				Aliases:     []string{"gh"},
+				Description: "GitHub",
+				Subcommands: []Subcommand{
+					{Name: "pr", Aliases: []string{"pull"}, Description: "Pull requests"},
+					{Name: "issues", Aliases: []string{"issue"}, Description: "Issues"},
+					{
+						Name:        "actions",
+						Description: "Actions",
+						Subcommands: []Subcommand{
+							{Name: "runs", Description: "Workflow runs"},
+							{Name: "workflows", Description: "Workflows"},
+						},
+					},
+				},
+			},
+		},
+	}
+	registry := NewCommandRegistry(config)
+
+	testCases := []struct {
+		query    string
+		expected []string
+	}{
+		{"g", []string{"github", "gmail", "google", "g", "gh"}},
+		{"GO", []string{"google"}},
+		{"gh", []string{"gh", "gh actions", "gh issues", "gh pr", "gh issue", "gh pull"}},
+		{"gh actions", []string{"gh actions", "gh actions runs", "gh actions workflows"}},
+		{"gh actions w", []string{"gh actions workflows"}},
+		{"gh nope w", nil},
+		{"github i", []string{"github issues", "github issue"}},
+		{"github pr x", nil},
+		{"  gh\tactions  w", []string{"gh actions workflows"}},
+		{"gh  ", []string{"gh actions", "gh issues", "gh pr", "gh issue", "gh pull"}},
+		{"unknown x", nil},
+	}
+
+	for _, tc := range testCases {
+		suggestions := registry.Suggest(tc.query)
+		if len(suggestions) != len(tc.expected) {
+			t.Errorf("Suggest(%q) returned %d suggestions, expected %v", tc.query, len(suggestions), tc.expected)
+			continue
+		}
+		for i, s := range suggestions {
+			if s.Completion != tc.expected[i] {
+				t.Errorf("Suggest(%q)[%d] = %q, expected %q", tc.query, i, s.Completion, tc.expected[i])
+			}
+		}
+	}
+
+	if suggestions := registry.Suggest("gh p"); len(suggestions) == 0 || suggestions[0].Description != "Pull requests" {
+		t.Errorf("Expected subcommand description as suggestion text, got %v", suggestions)
+	}
+}
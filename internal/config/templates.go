@@ -0,0 +1,128 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"text/template"
+)
+
+// TemplateError describes a URL template in the configuration that does not
+// compile, or that fails when executed with sample data
+type TemplateError struct {
+	Command    string // command name, empty outside commands
+	Subcommand string // subcommand path within Command, if any
+	Field      string // config field holding the template, e.g. "url"
+	Err        error
+}
+
+// Error names the template's location and the problem
+func (e *TemplateError) Error() string {
+	location := e.Field
+	if e.Subcommand != "" {
+		location = fmt.Sprintf("subcommand %q: %s", e.Subcommand, location)
+	}
+	if e.Command != "" {
+		location = fmt.Sprintf("command %q: %s", e.Command, location)
+	}
+	return location + ": " + e.Err.Error()
+}
+
+// Unwrap returns the underlying template error
+func (e *TemplateError) Unwrap() error {
+	return e.Err
+}
+
+// NewValidatedCommandRegistry creates a command registry like
+// NewCommandRegistry, but fails with every problem found in the
+// configuration so that a broken config is caught at startup
+func NewValidatedCommandRegistry(config *CommandConfig) (*CommandRegistry, error) {
+	registry := NewCommandRegistry(config)
+	if err := registry.Err(); err != nil {
+		return nil, err
+	}
+	return registry, nil
+}
+
+// Err returns the problems found while building the registry, joined into
+// one error, or nil if there were none
+func (r *CommandRegistry) Err() error {
+	return errors.Join(r.errs...)
+}
+
+// compileCommandTemplates compiles the templates of a command and its
+// subcommand tree, and checks their flags
+func (r *CommandRegistry) compileCommandTemplates(cmd *Command) {
+	cmd.urlTemplate = r.compileTemplate(cmd.URL, cmd.Params, cmd.Encoding, cmd.Name, "", "url")
+	if cmd.EmptyURL != "" {
+		cmd.emptyURLTemplate = r.compileTemplate(cmd.EmptyURL, nil, cmd.Encoding, cmd.Name, "", "emptyURL")
+	}
+	r.checkFlags(cmd.Name, "", cmd.Flags)
+	r.compileSubcommandTemplates(cmd.Name, "", cmd.Subcommands)
+}
+
+// compileSubcommandTemplates compiles the templates of one level of
+// subcommands and recurses into nested ones
+func (r *CommandRegistry) compileSubcommandTemplates(cmdName, parentPath string, subs []Subcommand) {
+	for i := range subs {
+		sub := &subs[i]
+		path := sub.Name
+		if parentPath != "" {
+			path = parentPath + " " + sub.Name
+		}
+		sub.urlTemplate = r.compileTemplate(sub.URL, sub.Params, sub.Encoding, cmdName, path, "url")
+		r.checkFlags(cmdName, path, sub.Flags)
+		r.compileSubcommandTemplates(cmdName, path, sub.Subcommands)
+	}
+}
+
+// compileFallbackTemplates compiles the templates of the "url" steps of the
+// fallback chain and the empty query URL
+func (r *CommandRegistry) compileFallbackTemplates() {
+	for i := range r.fallbackChain {
+		step := &r.fallbackChain[i]
+		if step.Type != FallbackURL {
+			continue
+		}
+		field := "fallback.chain[" + strconv.Itoa(i) + "].url"
+		step.urlTemplate = r.compileTemplate(step.URL, nil, step.Encoding, "", "", field)
+	}
+
+	if r.emptyQueryURL != "" {
+		r.emptyQueryTemplate = r.compileTemplate(r.emptyQueryURL, nil, "", "", "", "fallback.emptyURL")
+	}
+}
+
+// compileTemplate parses a URL template and executes it once with sample
+// data, which catches references to fields that do not exist. Problems are
+// recorded in r.errs and leave the template nil.
+func (r *CommandRegistry) compileTemplate(source string, params []Param, encoding, cmdName, subPath, field string) *template.Template {
+	fail := func(err error) *template.Template {
+		r.errs = append(r.errs, &TemplateError{Command: cmdName, Subcommand: subPath, Field: field, Err: err})
+		return nil
+	}
+
+	tmpl, err := parseURLTemplate(source)
+	if err != nil {
+		return fail(err)
+	}
+
+	sample, err := NewTemplateData(sampleQuery(params), params, nil, encoding)
+	if err != nil {
+		return fail(err)
+	}
+	if _, err := executeURLTemplate(tmpl, sample); err != nil {
+		return fail(err)
+	}
+
+	return tmpl
+}
+
+// sampleQuery returns a query that satisfies all params
+func sampleQuery(params []Param) string {
+	query := "sample"
+	for range params {
+		query += " sample"
+	}
+	return query
+}
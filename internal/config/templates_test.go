@@ -0,0 +1,132 @@
+package config
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestNewValidatedCommandRegistry(t *testing.T) {
+	config := &CommandConfig{
+		Commands: []Command{
+			{
+				Name: "github",
+				URL:  "https://github.com/search?q={{.Query}}",
+				Subcommands: []Subcommand{
+					{
+						Name: "pr",
+						URL:  "https://github.com/{{.Args.owner}}/{{.Args.repo}}/pulls",
+						Params: []Param{
+							{Name: "owner"},
+							{Name: "repo"},
+						},
+					},
+				},
+			},
+		},
+		Fallback: &FallbackConfig{
+			Chain:    []FallbackStep{{Type: FallbackURL, URL: "https://duckduckgo.com/?q={{.Query}}"}},
+			EmptyURL: "https://example.com/",
+		},
+	}
+
+	registry, err := NewValidatedCommandRegistry(config)
+	if err != nil {
+		t.Fatalf("Expected valid config, got %v", err)
+	}
+
+	cmd := registry.FindCommand("github")
+	if cmd.urlTemplate == nil || cmd.Subcommands[0].urlTemplate == nil {
+		t.Error("Expected command templates to be compiled")
+	}
+	if registry.GetFallbackChain()[0].urlTemplate == nil || registry.emptyQueryTemplate == nil {
+		t.Error("Expected fallback templates to be compiled")
+	}
+	if config.Fallback.Chain[0].urlTemplate != nil {
+		t.Error("Expected the configured fallback chain to be left untouched")
+	}
+}
+
+func TestNewValidatedCommandRegistry_Errors(t *testing.T) {
+	config := &CommandConfig{
+		Commands: []Command{
+			{Name: "ok", URL: "https://example.com/{{.Query}}"},
+			{Name: "unclosed", URL: "https://example.com/{{.Query"},
+			{Name: "field", URL: "https://example.com/{{.Search}}"},
+			{Name: "empty", URL: "https://example.com/{{.Query}}", EmptyURL: "{{if}}"},
+			{
+				Name: "github",
+				URL:  "https://github.com",
+				Subcommands: []Subcommand{
+					{
+						Name: "actions",
+						URL:  "https://github.com/actions",
+						Subcommands: []Subcommand{
+							{Name: "runs", URL: "https://github.com/{{.Query}"},
+						},
+					},
+				},
+			},
+			{Name: "encoded", URL: "https://example.com/{{.Query}}", Encoding: "rot13"},
+			{Name: "deploys", URL: "https://deploy.example.com/?env={{.Flags.env}}", Flags: []Flag{
+				{Name: "env", Default: "prd", Values: []string{"prod", "staging"}},
+			}, Subcommands: []Subcommand{
+				{Name: "last", URL: "https://deploy.example.com/last?n={{.Flags.n}}", Flags: []Flag{
+					{Name: "n", Type: "integer"},
+				}},
+			}},
+		},
+		Fallback: &FallbackConfig{
+			Chain: []FallbackStep{
+				{Type: FallbackPattern, Pattern: "^(x", URL: "https://example.com/{{.Query}}"},
+				{Type: FallbackURL, URL: "https://example.com/{{end}}"},
+			},
+		},
+	}
+
+	registry, err := NewValidatedCommandRegistry(config)
+	if err == nil {
+		t.Fatal("Expected validation error, got nil")
+	}
+	if registry != nil {
+		t.Error("Expected no registry for an invalid config")
+	}
+
+	message := err.Error()
+	for _, expected := range []string{
+		`command "unclosed": url: failed to parse URL template`,
+		`command "field": url: failed to execute URL template`,
+		`command "empty": emptyURL: failed to parse URL template`,
+		`command "github": subcommand "actions runs": url: failed to parse URL template`,
+		`command "encoded": url: unknown encoding "rot13"`,
+		`command "deploys": flags: flag -env has an invalid default`,
+		`command "deploys": subcommand "last": flags: flag -n has type "integer"`,
+		`fallback.chain[0].pattern "^(x"`,
+		`fallback.chain[1].url: failed to parse URL template`,
+	} {
+		if !strings.Contains(message, expected) {
+			t.Errorf("Expected error to mention %q, got:\n%s", expected, message)
+		}
+	}
+	if strings.Contains(message, `command "ok"`) {
+		t.Errorf("Did not expect valid command in error, got:\n%s", message)
+	}
+
+	var templateErr *TemplateError
+	if !errors.As(err, &templateErr) {
+		t.Error("Expected errors.As to find a TemplateError")
+	}
+}
+
+func TestNewCommandRegistry_BrokenTemplateFailsWhenUsed(t *testing.T) {
+	registry := NewCommandRegistry(&CommandConfig{
+		Commands: []Command{{Name: "broken", URL: "https://example.com/{{.Query"}},
+	})
+
+	if registry.Err() == nil {
+		t.Error("Expected registry to record the template error")
+	}
+	if _, err := registry.FindCommand("broken").Expand("x"); err == nil {
+		t.Error("Expected broken template to fail when used")
+	}
+}
@@ -0,0 +1,131 @@
+package config
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Token is one word of a query
+type Token struct {
+	Value string // the word with its quotes removed
+	Raw   string // the word as typed, quotes included
+	Start int    // byte offset of the word in the query
+}
+
+// Quote classes; smart quotes from mobile keyboards pair with their ASCII
+// counterparts
+const (
+	noQuote = iota
+	doubleQuote
+	singleQuote
+)
+
+// smartQuotes maps typographic quotes to the ASCII quote they stand for
+var smartQuotes = strings.NewReplacer(
+	"“", `"`, "”", `"`, "„", `"`, "‟", `"`,
+	"‘", "'", "’", "'", "‚", "'", "‛", "'",
+)
+
+// NormalizeQuotes replaces smart quotes with ASCII ones
+func NormalizeQuotes(s string) string {
+	return smartQuotes.Replace(s)
+}
+
+// Tokenize splits a query into words separated by any run of whitespace.
+// Text in single or double quotes, straight or smart, is part of one word,
+// so `"pull request" open` is two words. A quote only opens after a
+// non-alphanumeric character and only closes before one, so apostrophes as
+// in "don't" are kept, as is a quote that is never closed.
+func Tokenize(query string) []Token {
+	var tokens []Token
+	// Once a quote of a class finds no closer, none further on will either
+	var unclosed [3]bool
+
+	i := 0
+	for i < len(query) {
+		r, size := utf8.DecodeRuneInString(query[i:])
+		if unicode.IsSpace(r) {
+			i += size
+			continue
+		}
+
+		start := i
+		var value strings.Builder
+		for i < len(query) {
+			r, size := utf8.DecodeRuneInString(query[i:])
+			if unicode.IsSpace(r) {
+				break
+			}
+
+			if class := quoteClass(r); class != noQuote && !unclosed[class] && opensQuote(query, i) {
+				if end := closingQuote(query, i+size, class); end >= 0 {
+					value.WriteString(query[i+size : end])
+					_, closeSize := utf8.DecodeRuneInString(query[end:])
+					i = end + closeSize
+					continue
+				}
+				unclosed[class] = true
+			}
+
+			value.WriteRune(r)
+			i += size
+		}
+
+		tokens = append(tokens, Token{Value: value.String(), Raw: query[start:i], Start: start})
+	}
+
+	return tokens
+}
+
+// JoinTokens joins words as typed with single spaces and ASCII quotes,
+// the query with its whitespace collapsed
+func JoinTokens(tokens []Token) string {
+	raw := make([]string, len(tokens))
+	for i, token := range tokens {
+		raw[i] = token.Raw
+	}
+	return NormalizeQuotes(strings.Join(raw, " "))
+}
+
+// quoteClass returns the kind of quote r is, if any
+func quoteClass(r rune) int {
+	switch r {
+	case '"', '“', '”', '„', '‟':
+		return doubleQuote
+	case '\'', '‘', '’', '‚', '‛':
+		return singleQuote
+	}
+	return noQuote
+}
+
+// opensQuote reports whether the quote at offset i may open a quoted
+// section, which it can unless it follows a letter or digit
+func opensQuote(query string, i int) bool {
+	if i == 0 {
+		return true
+	}
+	prev, _ := utf8.DecodeLastRuneInString(query[:i])
+	return !isWordRune(prev)
+}
+
+// closingQuote returns the offset of the first quote of the given class
+// at or after i that is not followed by a letter or digit, or -1
+func closingQuote(query string, i, class int) int {
+	for i < len(query) {
+		r, size := utf8.DecodeRuneInString(query[i:])
+		if quoteClass(r) == class {
+			next, _ := utf8.DecodeRuneInString(query[i+size:])
+			if i+size == len(query) || !isWordRune(next) {
+				return i
+			}
+		}
+		i += size
+	}
+	return -1
+}
+
+// isWordRune reports whether r is part of a word for quoting purposes
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r)
+}
@@ -0,0 +1,80 @@
+package config
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTokenize(t *testing.T) {
+	tests := []struct {
+		query    string
+		expected []string
+	}{
+		{query: "gh pr flaky test", expected: []string{"gh", "pr", "flaky", "test"}},
+		{query: "  gh  pr\tx \n", expected: []string{"gh", "pr", "x"}},
+		{query: `gh "pull request" open`, expected: []string{"gh", "pull request", "open"}},
+		{query: `jira 'login page  broken'`, expected: []string{"jira", "login page  broken"}},
+		{query: `a "" b`, expected: []string{"a", "", "b"}},
+		{query: `title="two words" next`, expected: []string{"title=two words", "next"}},
+		// Smart quotes from mobile keyboards work like straight ones
+		{query: "g “exact phrase” now", expected: []string{"g", "exact phrase", "now"}},
+		{query: "g ‘exact phrase’", expected: []string{"g", "exact phrase"}},
+		// Apostrophes and stray quotes are kept
+		{query: "g don't panic", expected: []string{"g", "don't", "panic"}},
+		{query: "g don’t panic", expected: []string{"g", "don’t", "panic"}},
+		{query: "g rock 'n' roll", expected: []string{"g", "rock", "n", "roll"}},
+		{query: `g "unclosed quote`, expected: []string{"g", `"unclosed`, "quote"}},
+		{query: `g 12" pizza`, expected: []string{"g", `12"`, "pizza"}},
+		{query: "   "},
+		{query: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			var values []string
+			for _, token := range Tokenize(tt.query) {
+				values = append(values, token.Value)
+			}
+			if !reflect.DeepEqual(values, tt.expected) {
+				t.Errorf("Tokenize(%q) = %q, expected %q", tt.query, values, tt.expected)
+			}
+		})
+	}
+}
+
+func TestTokenize_Offsets(t *testing.T) {
+	query := ` gh  “a b”  x`
+	tokens := Tokenize(query)
+	if len(tokens) != 3 {
+		t.Fatalf("Expected 3 tokens, got %+v", tokens)
+	}
+	for _, token := range tokens {
+		if query[token.Start:token.Start+len(token.Raw)] != token.Raw {
+			t.Errorf("Token %+v does not match the query at its offset", token)
+		}
+	}
+	if tokens[1].Raw != "“a b”" {
+		t.Errorf("Expected the raw token to keep its quotes, got %q", tokens[1].Raw)
+	}
+	if joined := JoinTokens(tokens); joined != `gh "a b" x` {
+		t.Errorf("Expected collapsed whitespace and straight quotes, got %q", joined)
+	}
+}
+
+func TestTokenize_UnclosedQuotes(t *testing.T) {
+	// Every quote here opens and never closes; each used to rescan the rest
+	// of the query, which took seconds at this length
+	query := strings.Repeat(`-"a`, 35000) + ` 'b x`
+
+	start := time.Now()
+	tokens := Tokenize(query)
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Tokenize took %v for %d bytes", elapsed, len(query))
+	}
+
+	if len(tokens) != 3 || tokens[0].Value != query[:len(query)-5] || tokens[1].Value != "'b" || tokens[2].Value != "x" {
+		t.Errorf("Expected unclosed quotes to be kept as typed, got %d tokens", len(tokens))
+	}
+}
@@ -0,0 +1,88 @@
+package config
+
+import "sort"
+
+// trie maps keys to values and walks the keys sharing a prefix in sorted
+// order without looking at the others
+type trie[T any] struct {
+	root trieNode[T]
+}
+
+// trieNode holds the value of the key ending at it, if any
+type trieNode[T any] struct {
+	children map[rune]*trieNode[T]
+	value    T
+	set      bool
+}
+
+// newTrie creates an empty trie
+func newTrie[T any]() *trie[T] {
+	return &trie[T]{}
+}
+
+// insert sets the value of key, replacing any previous one
+func (t *trie[T]) insert(key string, value T) {
+	node := &t.root
+	for _, c := range key {
+		child, exists := node.children[c]
+		if !exists {
+			if node.children == nil {
+				node.children = make(map[rune]*trieNode[T])
+			}
+			child = &trieNode[T]{}
+			node.children[c] = child
+		}
+		node = child
+	}
+	node.value = value
+	node.set = true
+}
+
+// get returns the value of key
+func (t *trie[T]) get(key string) (T, bool) {
+	node := t.find(key)
+	if node == nil || !node.set {
+		var zero T
+		return zero, false
+	}
+	return node.value, true
+}
+
+// walk calls fn for every key starting with prefix, in sorted order
+func (t *trie[T]) walk(prefix string, fn func(key string, value T)) {
+	if node := t.find(prefix); node != nil {
+		node.walk([]rune(prefix), fn)
+	}
+}
+
+// find returns the node at the end of key, or nil when no key starts with it
+func (t *trie[T]) find(key string) *trieNode[T] {
+	if t == nil {
+		return nil
+	}
+	node := &t.root
+	for _, c := range key {
+		node = node.children[c]
+		if node == nil {
+			return nil
+		}
+	}
+	return node
+}
+
+// walk visits the node and its descendants depth first, children in order
+func (n *trieNode[T]) walk(key []rune, fn func(key string, value T)) {
+	if n.set {
+		fn(string(key), n.value)
+	}
+
+	runes := make([]rune, 0, len(n.children))
+	for c := range n.children {
+		runes = append(runes, c)
+	}
+	sort.Slice(runes, func(i, j int) bool { return runes[i] < runes[j] })
+
+	for _, c := range runes {
+		n.children[c].walk(append(key, c), fn)
+	}
+}
@@ -0,0 +1,58 @@
+package config
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestTrie(t *testing.T) {
+	tr := newTrie[int]()
+	for i, key := range []string{"stackoverflow", "stack", "github", "gh", "gist", "ステータス"} {
+		tr.insert(key, i)
+	}
+	tr.insert("gh", 10)
+
+	if value, ok := tr.get("gh"); !ok || value != 10 {
+		t.Errorf("Expected the replaced value 10 for gh, got %d, %v", value, ok)
+	}
+	if _, ok := tr.get("stac"); ok {
+		t.Error("Expected a prefix of a key not to be a key")
+	}
+	if _, ok := tr.get("stackoverflows"); ok {
+		t.Error("Expected an extension of a key not to be a key")
+	}
+	if value, ok := tr.get("ステータス"); !ok || value != 5 {
+		t.Errorf("Expected multibyte keys to work, got %d, %v", value, ok)
+	}
+
+	tests := []struct {
+		prefix   string
+		expected []string
+	}{
+		{prefix: "", expected: []string{"gh", "gist", "github", "stack", "stackoverflow", "ステータス"}},
+		{prefix: "g", expected: []string{"gh", "gist", "github"}},
+		{prefix: "stack", expected: []string{"stack", "stackoverflow"}},
+		{prefix: "ステ", expected: []string{"ステータス"}},
+		{prefix: "x"},
+	}
+
+	for _, tt := range tests {
+		if keys := walkKeys(tr, tt.prefix); !reflect.DeepEqual(keys, tt.expected) {
+			t.Errorf("walk(%q) visited %v, expected %v", tt.prefix, keys, tt.expected)
+		}
+	}
+
+	var empty *trie[int]
+	if keys := walkKeys(empty, ""); keys != nil {
+		t.Errorf("Expected a nil trie to have no keys, got %v", keys)
+	}
+}
+
+// walkKeys collects the keys walk visits, in order
+func walkKeys(t *trie[int], prefix string) []string {
+	var keys []string
+	t.walk(prefix, func(key string, _ int) {
+		keys = append(keys, key)
+	})
+	return keys
+}
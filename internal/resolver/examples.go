@@ -0,0 +1,57 @@
+package resolver
+
+import (
+	"github.com/olion500/gopherlol/internal/config"
+)
+
+// ExampleResult is the outcome of checking one example query
+type ExampleResult struct {
+	Command  string `json:"command"` // command path or pattern the example is defined on
+	Query    string `json:"query"`
+	Expected string `json:"expected"`
+	Got      string `json:"got,omitempty"`
+	Handler  string `json:"handler,omitempty"` // what resolved the query, see Result.Name
+	Error    string `json:"error,omitempty"`
+	Passed   bool   `json:"passed"`
+}
+
+// CheckExamples resolves the example queries of every command, subcommand
+// and pattern and compares the URLs they go to with the expected ones
+func (r *Resolver) CheckExamples() []ExampleResult {
+	var results []ExampleResult
+	for _, cmd := range r.registry.ListCommands() {
+		results = r.checkExamples(results, cmd.Name, cmd.Examples)
+		results = r.checkSubcommandExamples(results, cmd.Name, cmd.Subcommands)
+	}
+	for _, pattern := range r.registry.ListPatterns() {
+		results = r.checkExamples(results, pattern.Label(), pattern.Examples)
+	}
+	return results
+}
+
+// checkSubcommandExamples checks the examples of a subcommand tree
+func (r *Resolver) checkSubcommandExamples(results []ExampleResult, prefix string, subs []config.Subcommand) []ExampleResult {
+	for _, sub := range subs {
+		path := prefix + " " + sub.Name
+		results = r.checkExamples(results, path, sub.Examples)
+		results = r.checkSubcommandExamples(results, path, sub.Subcommands)
+	}
+	return results
+}
+
+// checkExamples checks the examples defined on one command path
+func (r *Resolver) checkExamples(results []ExampleResult, path string, examples []config.Example) []ExampleResult {
+	for _, example := range examples {
+		res, _ := r.Resolve(example.Query)
+		results = append(results, ExampleResult{
+			Command:  path,
+			Query:    example.Query,
+			Expected: example.URL,
+			Got:      res.URL,
+			Handler:  res.Name(),
+			Error:    res.Error,
+			Passed:   res.Action == ActionRedirect && res.URL == example.URL,
+		})
+	}
+	return results
+}
@@ -0,0 +1,422 @@
+// Package resolver turns queries into destinations. It holds all parsing,
+// subcommand detection, fallback and template execution so that the HTTP
+// server, the command line and library consumers resolve queries the same
+// way.
+package resolver
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/olion500/gopherlol/internal/config"
+)
+
+// Actions a Result can ask for
+const (
+	ActionRedirect   = "redirect"     // go to URL
+	ActionHelp       = "help"         // show the command list
+	ActionDidYouMean = "did-you-mean" // offer Candidates, URL searches anyway
+	ActionAmbiguous  = "ambiguous"    // offer Candidates for Abbreviation, URL searches anyway
+	ActionError      = "error"        // show Error
+)
+
+// Result describes where a query goes
+type Result struct {
+	Query         string      `json:"query"`
+	Action        string      `json:"action"`
+	Command       string      `json:"command,omitempty"`
+	Subcommand    string      `json:"subcommand,omitempty"`
+	Alias         string      `json:"alias,omitempty"`
+	Pattern       string      `json:"pattern,omitempty"`
+	Navigation    bool        `json:"navigation,omitempty"`
+	Autocorrected bool        `json:"autocorrected,omitempty"`
+	Abbreviation  string      `json:"abbreviation,omitempty"` // prefix typed for a command or subcommand name
+	URL           string      `json:"url,omitempty"`
+	Fallback      bool        `json:"fallback"`
+	FallbackStep  string      `json:"fallbackStep,omitempty"`
+	Candidates    []Candidate `json:"candidates,omitempty"`
+	Error         string      `json:"error,omitempty"`
+	Usage         string      `json:"usage,omitempty"`
+	Options       []string    `json:"options,omitempty"` // valid values of the flag named in Error
+}
+
+// Candidate is a command offered on the did-you-mean page
+type Candidate struct {
+	Command     string `json:"command"`
+	Query       string `json:"query"`
+	Description string `json:"description,omitempty"`
+}
+
+// Client identifies who a query was resolved for
+type Client struct {
+	UserAgent  string
+	RemoteAddr string
+}
+
+// Observer is notified of resolutions made on behalf of a client
+type Observer interface {
+	Observe(result Result, client Client)
+}
+
+// ObserverFunc adapts a function to the Observer interface
+type ObserverFunc func(result Result, client Client)
+
+// Observe calls f(result, client)
+func (f ObserverFunc) Observe(result Result, client Client) {
+	f(result, client)
+}
+
+// Resolver resolves queries against a command registry
+type Resolver struct {
+	registry  *config.CommandRegistry
+	observers []Observer
+}
+
+// New creates a resolver for the registry. Observers are notified by
+// ResolveFor, never by Resolve.
+func New(registry *config.CommandRegistry, observers ...Observer) *Resolver {
+	return &Resolver{
+		registry:  registry,
+		observers: observers,
+	}
+}
+
+// Registry returns the command registry queries are resolved against
+func (r *Resolver) Registry() *config.CommandRegistry {
+	return r.registry
+}
+
+// Name identifies what handled the query, subcommand path included, for
+// error messages and pages
+func (res Result) Name() string {
+	if res.Command == "" {
+		return res.handler()
+	}
+	if res.Subcommand == "" {
+		return res.Command
+	}
+	return res.Command + " " + res.Subcommand
+}
+
+// UsageCommand identifies what handled the query in the usage log: the
+// command without its subcommands, so their counts add up to the command's
+func (res Result) UsageCommand() string {
+	if res.Command == "" {
+		return res.handler()
+	}
+	return res.Command
+}
+
+// handler names what handled a query no command did
+func (res Result) handler() string {
+	switch {
+	case res.Pattern != "":
+		return res.Pattern
+	case res.Navigation:
+		return "navigation"
+	case res.Action == ActionAmbiguous:
+		return "ambiguous"
+	default:
+		return res.FallbackStep
+	}
+}
+
+// redirect records the outcome of building the target URL
+func (res Result) redirect(targetURL string, err error) (Result, error) {
+	if err != nil {
+		res.Action = ActionError
+		res.Error = err.Error()
+		var argErr *config.ArgumentError
+		if errors.As(err, &argErr) {
+			res.Usage = strings.TrimSpace(res.Name() + " " + argErr.Usage())
+		}
+		var flagErr *config.FlagError
+		if errors.As(err, &flagErr) {
+			res.Options = flagErr.Flag.Options()
+		}
+		return res, err
+	}
+
+	res.Action = ActionRedirect
+	res.URL = targetURL
+	return res, nil
+}
+
+// ResolveFor resolves the query and notifies the observers
+func (r *Resolver) ResolveFor(query string, client Client) (Result, error) {
+	res, err := r.Resolve(query)
+	for _, observer := range r.observers {
+		observer.Observe(res, client)
+	}
+	return res, err
+}
+
+// Resolve works out where a query goes. It has no side effects. The
+// returned error is set when building the target URL failed, and is also
+// described in the result.
+func (r *Resolver) Resolve(query string) (Result, error) {
+	res := Result{Query: query}
+
+	// Split the query into words, respecting quotes
+	tokens := config.Tokenize(query)
+	parts := make([]string, len(tokens))
+	for i, token := range tokens {
+		parts[i] = token.Value
+	}
+	cmdName := ""
+	if len(parts) > 0 {
+		cmdName = strings.ToLower(parts[0])
+	}
+	trimmed := strings.TrimSpace(query)
+
+	// Handle help/list commands
+	if cmdName == "list" || cmdName == "help" {
+		res.Action = ActionHelp
+		res.Command = "help"
+		return res, nil
+	}
+
+	// An empty query goes to its own page when one is configured
+	if len(tokens) == 0 && r.registry.GetEmptyQueryURL() != "" {
+		res.Fallback = true
+		res.FallbackStep = "empty-query"
+		return res.redirect(r.registry.ExpandEmptyQueryURL())
+	}
+
+	// Try to find the command, then a command it abbreviates
+	cmd := r.registry.FindCommand(cmdName)
+	if cmd == nil {
+		matches := r.registry.FindCommandsByPrefix(cmdName)
+		if len(matches) > 1 {
+			return r.disambiguateCommand(res, tokens, matches), nil
+		}
+		if len(matches) == 1 {
+			res.Abbreviation = cmdName
+			cmd = matches[0].Command
+			cmdName = matches[0].Key
+		}
+	}
+	if cmd == nil {
+		// Queries without a keyword may still match a pattern
+		if pattern, groups := r.registry.MatchPattern(trimmed); pattern != nil {
+			res.Pattern = pattern.Label()
+			return res.redirect(pattern.Expand(trimmed, groups))
+		}
+
+		// Addresses are navigated to rather than searched for
+		if target, ok := r.registry.NavigationURL(trimmed); ok {
+			res.Navigation = true
+			return res.redirect(target, nil)
+		}
+
+		// Command not found => walk the fallback chain
+		fallback, corrected, err := r.runFallback(res, tokens, true)
+		if corrected == nil {
+			return fallback, err
+		}
+		// A fuzzy step corrected the command name, carry on with it
+		cmd = corrected.Command
+		cmdName = corrected.Key
+		res.Autocorrected = true
+	}
+
+	res.Command = cmd.Name
+	if cmdName != strings.ToLower(cmd.Name) {
+		res.Alias = cmdName
+	}
+
+	// Check for subcommands
+	if len(parts) >= 2 {
+		// Walk the subcommand tree as far as the arguments match
+		path, ambiguous := r.registry.MatchSubcommandPath(cmdName, parts[1:])
+		if len(ambiguous) > 0 {
+			return disambiguateSubcommand(res, cmd, cmdName, tokens, path, ambiguous), nil
+		}
+		if len(path) > 0 {
+			// Found subcommand, use remaining parts as query
+			subCmd := path[len(path)-1]
+			res.Subcommand = subcommandPathName(path)
+			return res.redirect(subCmd.Expand(remainder(query, tokens, 1+len(path))))
+		}
+
+		// No subcommand found, treat everything after command as query
+		return res.redirect(cmd.Expand(remainder(query, tokens, 1)))
+	}
+
+	// No arguments, just the command
+	if cmd.RequiresQuery {
+		// Command requires query but none provided => use its empty URL
+		// if it has one, otherwise ask for the query rather than search
+		// for the command name
+		if cmd.EmptyURL == "" {
+			return res.redirect("", &config.ArgumentError{Params: []config.Param{{Name: "query"}}, Missing: []string{"query"}})
+		}
+		return res.redirect(cmd.ExpandEmpty())
+	}
+
+	return res.redirect(cmd.Expand(""))
+}
+
+// runFallback walks the fallback chain for a query that no command handles.
+// The first step that applies decides the outcome. A fuzzy step may instead
+// correct the command name, in which case the match is returned and the
+// caller carries on with the corrected command. Fuzzy steps are skipped
+// unless allowFuzzy is set and did-you-mean is not turned off.
+func (r *Resolver) runFallback(res Result, tokens []config.Token, allowFuzzy bool) (Result, *config.FuzzyMatch, error) {
+	q := res.Query
+	cmdName := ""
+	if len(tokens) > 0 {
+		cmdName = strings.ToLower(tokens[0].Value)
+	}
+	res.Fallback = true
+
+	for _, step := range r.registry.GetFallbackChain() {
+		switch step.Type {
+		case config.FallbackFuzzy:
+			if !allowFuzzy {
+				continue
+			}
+			settings := r.registry.GetDidYouMean()
+			if settings.Mode == config.DidYouMeanOff {
+				continue
+			}
+			matches := r.registry.FindSimilarCommands(cmdName, settings.MaxDistance)
+			if len(matches) == 0 {
+				continue
+			}
+			if settings.Mode == config.DidYouMeanAutocorrect && len(matches) == 1 {
+				return res, &matches[0], nil
+			}
+
+			res.Action = ActionDidYouMean
+			res.FallbackStep = "did-you-mean"
+			rest := remainder(q, tokens, 1)
+			for _, match := range matches {
+				res.Candidates = append(res.Candidates, Candidate{
+					Command:     match.Command.Name,
+					Query:       strings.TrimSpace(match.Key + " " + rest),
+					Description: match.Command.Description,
+				})
+			}
+			// Offer the search the rest of the chain would have done
+			if search, _, err := r.runFallback(Result{Query: q}, tokens, false); err == nil && search.Action == ActionRedirect {
+				res.URL = search.URL
+			}
+			return res, nil, nil
+
+		case config.FallbackDefault:
+			defaultCmd := r.registry.GetDefaultCommand()
+			if defaultCmd == nil {
+				continue
+			}
+			// Use default command with full query as search term
+			res.Command = defaultCmd.Name
+			res.FallbackStep = step.Label()
+			res, err := res.redirect(defaultCmd.Expand(q))
+			return res, nil, err
+
+		case config.FallbackPattern:
+			// Patterns that fail to compile never match. Like the patterns
+			// section, they see the query without surrounding whitespace.
+			trimmed := strings.TrimSpace(q)
+			pattern, groups := step.MatchPattern(trimmed)
+			if pattern == nil {
+				continue
+			}
+			res.FallbackStep = step.Label()
+			res, err := res.redirect(pattern.Expand(trimmed, groups))
+			return res, nil, err
+
+		case config.FallbackURL:
+			res.FallbackStep = step.Label()
+			res, err := res.redirect(step.Expand(q))
+			return res, nil, err
+
+		case config.FallbackError:
+			res.Action = ActionError
+			res.FallbackStep = step.Label()
+			res.Error = step.Message
+			if res.Error == "" {
+				res.Error = fmt.Sprintf("No command matches %q.", q)
+			}
+			return res, nil, nil
+		}
+	}
+
+	res.Action = ActionError
+	res.FallbackStep = "no-fallback"
+	res.Error = fmt.Sprintf("No command matches %q.", q)
+	return res, nil, nil
+}
+
+// disambiguateCommand offers the commands an abbreviated command name may
+// stand for, and the search the fallback chain would do instead
+func (r *Resolver) disambiguateCommand(res Result, tokens []config.Token, matches []config.CommandMatch) Result {
+	res.Action = ActionAmbiguous
+	res.Abbreviation = strings.ToLower(tokens[0].Value)
+	rest := remainder(res.Query, tokens, 1)
+	for _, match := range matches {
+		res.Candidates = append(res.Candidates, Candidate{
+			Command:     match.Command.Name,
+			Query:       strings.TrimSpace(match.Key + " " + rest),
+			Description: match.Command.Description,
+		})
+	}
+	if search, _, err := r.runFallback(Result{Query: res.Query}, tokens, false); err == nil && search.Action == ActionRedirect {
+		res.URL = search.URL
+	}
+	return res
+}
+
+// disambiguateSubcommand offers the subcommands an abbreviated subcommand
+// name may stand for, and the URL the abbreviation would go to as a search
+// term of the subcommands matched before it
+func disambiguateSubcommand(res Result, cmd *config.Command, cmdName string, tokens []config.Token, path []*config.Subcommand, matches []config.SubcommandMatch) Result {
+	res.Action = ActionAmbiguous
+	res.Command = cmd.Name
+	res.Subcommand = subcommandPathName(path)
+
+	typed := 1 + len(path)
+	res.Abbreviation = strings.ToLower(tokens[typed].Value)
+	prefix := strings.TrimSpace(cmdName + " " + res.Subcommand)
+	rest := remainder(res.Query, tokens, typed+1)
+	for _, match := range matches {
+		res.Candidates = append(res.Candidates, Candidate{
+			Command:     strings.TrimSpace(res.Name() + " " + match.Subcommand.Name),
+			Query:       strings.TrimSpace(prefix + " " + match.Key + " " + rest),
+			Description: match.Subcommand.Description,
+		})
+	}
+
+	var search string
+	var err error
+	if len(path) > 0 {
+		search, err = path[len(path)-1].Expand(remainder(res.Query, tokens, typed))
+	} else {
+		search, err = cmd.Expand(remainder(res.Query, tokens, typed))
+	}
+	if err == nil {
+		res.URL = search
+	}
+	return res
+}
+
+// remainder returns the query exactly as typed from its nth word on,
+// without trailing whitespace
+func remainder(query string, tokens []config.Token, n int) string {
+	if n >= len(tokens) {
+		return ""
+	}
+	return strings.TrimRightFunc(query[tokens[n].Start:], unicode.IsSpace)
+}
+
+// subcommandPathName joins the names of nested subcommands, e.g. "logs prod"
+func subcommandPathName(path []*config.Subcommand) string {
+	names := make([]string, len(path))
+	for i, sub := range path {
+		names[i] = sub.Name
+	}
+	return strings.Join(names, " ")
+}
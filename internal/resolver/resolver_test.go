@@ -0,0 +1,635 @@
+package resolver
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/olion500/gopherlol/internal/config"
+)
+
+func newTestConfig() *config.CommandConfig {
+	return &config.CommandConfig{
+		Commands: []config.Command{
+			{
+				Name:          "google",
+				Aliases:       []string{"g"},
+				Description:   "Search Google",
+				URL:           "https://www.google.com/?q={{.Query}}",
+				RequiresQuery: true,
+				Default:       true,
+			},
+			{
+				Name:          "stackoverflow",
+				Aliases:       []string{"so"},
+				Description:   "Search Stack Overflow",
+				URL:           "https://stackoverflow.com/search?q={{.Query}}",
+				RequiresQuery: true,
+				EmptyURL:      "https://stackoverflow.com/questions",
+			},
+			{
+				Name:        "pulls",
+				Description: "Pull requests of a repository",
+				URL:         "https://github.com/{{.Args.owner}}/{{.Args.repo}}/pulls",
+				Params:      []config.Param{{Name: "owner"}, {Name: "repo"}},
+			},
+			{
+				Name:        "broken",
+				Description: "Broken template",
+				URL:         "https://example.com/{{.Missing}}",
+			},
+			{
+				Name:        "github",
+				Aliases:     []string{"gh"},
+				Description: "GitHub operations",
+				URL:         "https://github.com/search?q={{.Query}}",
+				Subcommands: []config.Subcommand{
+					{
+						Name:        "pr",
+						Aliases:     []string{"pull"},
+						Description: "GitHub pull requests",
+						URL:         "https://github.com/search?type=pullrequests&q={{.Query}}",
+					},
+				},
+			},
+		},
+	}
+}
+
+func newTestResolver(cfg *config.CommandConfig, observers ...Observer) *Resolver {
+	return New(config.NewCommandRegistry(cfg), observers...)
+}
+
+func TestResolve(t *testing.T) {
+	r := newTestResolver(newTestConfig())
+
+	testCases := []struct {
+		query    string
+		expected Result
+	}{
+		{
+			query: "gh pull flaky test",
+			expected: Result{
+				Query:      "gh pull flaky test",
+				Action:     ActionRedirect,
+				Command:    "github",
+				Subcommand: "pr",
+				Alias:      "gh",
+				URL:        "https://github.com/search?type=pullrequests&q=flaky+test",
+			},
+		},
+		{
+			query: "unknown query",
+			expected: Result{
+				Query:        "unknown query",
+				Action:       ActionRedirect,
+				Command:      "google",
+				URL:          "https://www.google.com/?q=unknown+query",
+				Fallback:     true,
+				FallbackStep: "default-fallback",
+			},
+		},
+		{
+			query: "so",
+			expected: Result{
+				Query:   "so",
+				Action:  ActionRedirect,
+				Command: "stackoverflow",
+				Alias:   "so",
+				URL:     "https://stackoverflow.com/questions",
+			},
+		},
+		{
+			query: "help",
+			expected: Result{
+				Query:   "help",
+				Action:  ActionHelp,
+				Command: "help",
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.query, func(t *testing.T) {
+			res, err := r.Resolve(tc.query)
+			if err != nil {
+				t.Fatalf("Resolve(%q) failed: %v", tc.query, err)
+			}
+			if !reflect.DeepEqual(res, tc.expected) {
+				t.Errorf("Resolve(%q) = %+v, expected %+v", tc.query, res, tc.expected)
+			}
+		})
+	}
+}
+
+func TestResolve_RequiresQueryWithoutEmptyURL(t *testing.T) {
+	r := newTestResolver(newTestConfig())
+
+	// The default command is not run with its own name as the query
+	res, err := r.Resolve("g")
+	var argErr *config.ArgumentError
+	if !errors.As(err, &argErr) {
+		t.Fatalf("Expected an argument error, got %v", err)
+	}
+	if res.Action != ActionError || res.Fallback || res.URL != "" || res.Command != "google" || res.Usage != "google <query>" {
+		t.Errorf("Expected a missing query error for google, got %+v", res)
+	}
+}
+
+func TestResolve_Errors(t *testing.T) {
+	r := newTestResolver(newTestConfig())
+
+	res, err := r.Resolve("pulls olion500")
+	var argErr *config.ArgumentError
+	if !errors.As(err, &argErr) {
+		t.Fatalf("Expected ArgumentError, got %v", err)
+	}
+	if res.Action != ActionError || res.Error != "missing required argument <repo>" || res.Usage != "pulls <owner> <repo>" {
+		t.Errorf("Expected missing argument result, got %+v", res)
+	}
+
+	res, err = r.Resolve("broken")
+	if err == nil {
+		t.Fatal("Expected template error, got nil")
+	}
+	if res.Action != ActionError || res.Command != "broken" || res.Error != err.Error() {
+		t.Errorf("Expected template error in result, got %+v", res)
+	}
+}
+
+func TestResolve_FallbackChain(t *testing.T) {
+	cfg := newTestConfig()
+	cfg.Fallback = &config.FallbackConfig{
+		Chain: []config.FallbackStep{
+			{Type: config.FallbackPattern, Pattern: `^[A-Z]+-\d+$`, URL: "https://jira.example.com/browse/{{.Query}}"},
+			{Type: config.FallbackURL, URL: "https://duckduckgo.com/?q={{.Query}}"},
+		},
+	}
+	r := newTestResolver(cfg)
+
+	testCases := []struct {
+		query    string
+		expected string
+		step     string
+	}{
+		{"PROJ-123", "https://jira.example.com/browse/PROJ-123", "pattern-fallback"},
+		// Surrounding whitespace is ignored, as in the patterns section
+		{"  PROJ-1 ", "https://jira.example.com/browse/PROJ-1", "pattern-fallback"},
+		{"unknown words", "https://duckduckgo.com/?q=unknown+words", "url-fallback"},
+		// The default command is not part of this chain
+		{"", "https://duckduckgo.com/?q=", "url-fallback"},
+	}
+
+	for _, tc := range testCases {
+		res, err := r.Resolve(tc.query)
+		if err != nil {
+			t.Errorf("Resolve(%q) failed: %v", tc.query, err)
+			continue
+		}
+		if res.URL != tc.expected || res.FallbackStep != tc.step || !res.Fallback {
+			t.Errorf("Resolve(%q) = %+v, expected %q via %s", tc.query, res, tc.expected, tc.step)
+		}
+	}
+}
+
+func TestResolve_Patterns(t *testing.T) {
+	cfg := newTestConfig()
+	cfg.DidYouMean = &config.DidYouMeanConfig{Mode: config.DidYouMeanSuggest}
+	cfg.Patterns = []config.Pattern{
+		{Name: "jira-key", Match: `(?P<key>[A-Z]+-\d+)`, URL: "https://jira.example.com/browse/{{.Args.key}}"},
+		{Name: "incident", Match: `INC(?P<id>\d+)`, URL: "https://incidents.example.com/{{.Args.id}}"},
+		{Name: "shadowed", Match: `so \w+`, URL: "https://example.com/never"},
+	}
+	r := newTestResolver(cfg)
+
+	testCases := []struct {
+		query    string
+		expected string
+		pattern  string
+	}{
+		{"PROJ-1234", "https://jira.example.com/browse/PROJ-1234", "jira-key"},
+		{"INC0012345", "https://incidents.example.com/0012345", "incident"},
+		{"  PROJ-1 ", "https://jira.example.com/browse/PROJ-1", "jira-key"},
+		// Commands are found before patterns are tried
+		{"so test", "https://stackoverflow.com/search?q=test", ""},
+		// Anything else goes on to the fallback chain, default command included
+		{"PROJ-1234 is broken", "https://www.google.com/?q=PROJ-1234+is+broken", ""},
+	}
+
+	for _, tc := range testCases {
+		res, err := r.Resolve(tc.query)
+		if err != nil {
+			t.Errorf("Resolve(%q) failed: %v", tc.query, err)
+			continue
+		}
+		if res.URL != tc.expected || res.Pattern != tc.pattern {
+			t.Errorf("Resolve(%q) = %+v, expected %q via pattern %q", tc.query, res, tc.expected, tc.pattern)
+		}
+		if tc.pattern != "" && (res.Fallback || res.Name() != tc.pattern) {
+			t.Errorf("Resolve(%q) = %+v, expected a pattern match named %q", tc.query, res, tc.pattern)
+		}
+	}
+}
+
+func TestResolve_Navigation(t *testing.T) {
+	cfg := newTestConfig()
+	cfg.Navigation = &config.NavigationConfig{Suffixes: []string{"internal"}}
+	cfg.Patterns = []config.Pattern{
+		{Name: "docs", Match: `docs\.internal`, URL: "https://docs.example.com"},
+	}
+	r := newTestResolver(cfg)
+
+	testCases := []struct {
+		query      string
+		expected   string
+		navigation bool
+	}{
+		{"grafana.internal", "https://grafana.internal", true},
+		{"localhost:3000/debug", "http://localhost:3000/debug", true},
+		{"https://grafana.internal/x", "https://grafana.internal/x", true},
+		// Other sites are not redirected to
+		{"https://example.com/x", "https://www.google.com/?q=https%3A%2F%2Fexample.com%2Fx", false},
+		// Patterns are tried before navigation
+		{"docs.internal", "https://docs.example.com", false},
+		// Other names go to the fallback chain
+		{"node.js", "https://www.google.com/?q=node.js", false},
+	}
+
+	for _, tc := range testCases {
+		res, err := r.Resolve(tc.query)
+		if err != nil {
+			t.Errorf("Resolve(%q) failed: %v", tc.query, err)
+			continue
+		}
+		if res.URL != tc.expected || res.Navigation != tc.navigation {
+			t.Errorf("Resolve(%q) = %+v, expected %q with navigation %v", tc.query, res, tc.expected, tc.navigation)
+		}
+		if tc.navigation && (res.Fallback || res.Name() != "navigation") {
+			t.Errorf("Resolve(%q) = %+v, expected a navigation", tc.query, res)
+		}
+	}
+}
+
+func TestResolve_FallbackErrorStep(t *testing.T) {
+	cfg := newTestConfig()
+	cfg.Fallback = &config.FallbackConfig{
+		Chain: []config.FallbackStep{{Type: config.FallbackError, Message: "Offline mode"}},
+	}
+	r := newTestResolver(cfg)
+
+	res, err := r.Resolve("unknown")
+	if err != nil {
+		t.Fatalf("Resolve failed: %v", err)
+	}
+	if res.Action != ActionError || res.Error != "Offline mode" {
+		t.Errorf("Expected error step result, got %+v", res)
+	}
+}
+
+func TestResolve_FallbackChainExhausted(t *testing.T) {
+	cfg := newTestConfig()
+	cfg.Fallback = &config.FallbackConfig{
+		Chain: []config.FallbackStep{
+			{Type: config.FallbackPattern, Pattern: `^#\d+$`, URL: "https://example.com/pr/{{.Raw}}"},
+		},
+	}
+	r := newTestResolver(cfg)
+
+	res, _ := r.Resolve("unknown")
+	if res.Action != ActionError || res.FallbackStep != "no-fallback" {
+		t.Errorf("Expected error when no step applies, got %+v", res)
+	}
+}
+
+func TestResolve_EmptyQueryURL(t *testing.T) {
+	cfg := newTestConfig()
+	cfg.Fallback = &config.FallbackConfig{EmptyURL: "https://intranet.example.com/"}
+	r := newTestResolver(cfg)
+
+	res, err := r.Resolve("")
+	if err != nil {
+		t.Fatalf("Resolve failed: %v", err)
+	}
+	if res.URL != "https://intranet.example.com/" || res.FallbackStep != "empty-query" {
+		t.Errorf("Expected empty query URL, got %+v", res)
+	}
+}
+
+func TestResolve_NoDefaultCommand(t *testing.T) {
+	cfg := newTestConfig()
+	for i := range cfg.Commands {
+		cfg.Commands[i].Default = false
+	}
+	r := newTestResolver(cfg)
+
+	res, _ := r.Resolve("unknown query")
+	if res.URL != "https://www.google.com/?q=unknown+query" || res.FallbackStep != "google-fallback" {
+		t.Errorf("Expected Google fallback, got %+v", res)
+	}
+}
+
+func TestResolve_DidYouMean(t *testing.T) {
+	cfg := newTestConfig()
+	cfg.DidYouMean = &config.DidYouMeanConfig{Mode: config.DidYouMeanSuggest}
+	r := newTestResolver(cfg)
+
+	res, err := r.Resolve("stackoverfow foo")
+	if err != nil {
+		t.Fatalf("Resolve failed: %v", err)
+	}
+	if res.Action != ActionDidYouMean || !res.Fallback {
+		t.Errorf("Expected did-you-mean fallback, got %+v", res)
+	}
+	if len(res.Candidates) != 1 || res.Candidates[0].Query != "stackoverflow foo" {
+		t.Errorf("Expected stackoverflow candidate, got %+v", res.Candidates)
+	}
+	if res.URL != "https://www.google.com/?q=stackoverfow+foo" {
+		t.Errorf("Expected search anyway URL, got %q", res.URL)
+	}
+}
+
+func TestResolve_FuzzyStepWithDidYouMeanOff(t *testing.T) {
+	cfg := newTestConfig()
+	cfg.Fallback = &config.FallbackConfig{
+		Chain: []config.FallbackStep{
+			{Type: config.FallbackFuzzy},
+			{Type: config.FallbackURL, URL: "https://duckduckgo.com/?q={{.Query}}"},
+		},
+	}
+	r := newTestResolver(cfg)
+
+	res, err := r.Resolve("stackoverfow foo")
+	if err != nil {
+		t.Fatalf("Resolve failed: %v", err)
+	}
+	if res.Action != ActionRedirect || res.URL != "https://duckduckgo.com/?q=stackoverfow+foo" || res.FallbackStep != "url-fallback" {
+		t.Errorf("Expected the fuzzy step to be skipped with did-you-mean off, got %+v", res)
+	}
+}
+
+func TestResolve_Tokenizes(t *testing.T) {
+	cfg := newTestConfig()
+	cfg.Commands = append(cfg.Commands, config.Command{
+		Name:   "raw",
+		URL:    "https://example.com/?raw={{.Raw}}&first={{index .Args 0}}",
+		Params: []config.Param{{Name: "first"}},
+	})
+	r := newTestResolver(cfg)
+
+	testCases := []struct {
+		query      string
+		expected   string
+		subcommand string
+	}{
+		{"  gh  pr\tflaky   test ", "https://github.com/search?type=pullrequests&q=flaky+test", "pr"},
+		{`gh pr "flaky test"`, "https://github.com/search?type=pullrequests&q=%22flaky+test%22", "pr"},
+		{"gh pr “flaky test”", "https://github.com/search?type=pullrequests&q=%22flaky+test%22", "pr"},
+		{`pulls "olion500" 'gopherlol'`, "https://github.com/olion500/gopherlol/pulls", ""},
+		// .Raw is the rest of the query exactly as typed
+		{`raw  "a  b"  c `, `https://example.com/?raw="a  b"  c&first=a++b`, ""},
+		// Whitespace alone is an empty query
+		{"   ", "https://www.google.com/?q=", ""},
+	}
+
+	for _, tc := range testCases {
+		res, err := r.Resolve(tc.query)
+		if err != nil {
+			t.Errorf("Resolve(%q) failed: %v", tc.query, err)
+			continue
+		}
+		if res.URL != tc.expected || res.Subcommand != tc.subcommand {
+			t.Errorf("Resolve(%q) = %+v, expected %q via %q", tc.query, res, tc.expected, tc.subcommand)
+		}
+	}
+}
+
+func TestResolve_Flags(t *testing.T) {
+	cfg := newTestConfig()
+	cfg.Commands = append(cfg.Commands, config.Command{
+		Name: "dd",
+		URL:  "https://app.datadoghq.com",
+		Subcommands: []config.Subcommand{{
+			Name: "logs",
+			URL:  "https://app.datadoghq.com/logs?query={{.Query}}&env={{.Flags.env}}&from={{.Flags.since}}",
+			Flags: []config.Flag{
+				{Name: "env", Values: []string{"prod", "staging"}, Default: "prod"},
+				{Name: "since", Type: config.FlagDuration, Default: "15m"},
+			},
+		}},
+	})
+	r := newTestResolver(cfg)
+
+	res, err := r.Resolve("dd logs -env staging -since 1h timeout")
+	if err != nil {
+		t.Fatalf("Resolve failed: %v", err)
+	}
+	if res.URL != "https://app.datadoghq.com/logs?query=timeout&env=staging&from=1h" {
+		t.Errorf("Unexpected URL %q", res.URL)
+	}
+
+	res, err = r.Resolve("dd logs -env qa timeout")
+	var flagErr *config.FlagError
+	if !errors.As(err, &flagErr) {
+		t.Fatalf("Expected FlagError, got %v", err)
+	}
+	if res.Action != ActionError || res.Name() != "dd logs" {
+		t.Errorf("Expected an error for dd logs, got %+v", res)
+	}
+	if !reflect.DeepEqual(res.Options, []string{"prod", "staging"}) {
+		t.Errorf("Expected the valid values as options, got %v", res.Options)
+	}
+}
+
+func TestResolve_Abbreviations(t *testing.T) {
+	cfg := newTestConfig()
+	cfg.Commands[4].Subcommands = append(cfg.Commands[4].Subcommands,
+		config.Subcommand{Name: "issues", Description: "GitHub issues", URL: "https://github.com/issues?q={{.Query}}"},
+		config.Subcommand{Name: "issue-templates", Description: "Issue templates", URL: "https://github.com/templates?q={{.Query}}"},
+		config.Subcommand{Name: "projects", Description: "GitHub projects", URL: "https://github.com/projects?q={{.Query}}"},
+	)
+	cfg.Commands = append(cfg.Commands, config.Command{
+		Name:        "stackexchange",
+		Description: "Search Stack Exchange",
+		URL:         "https://stackexchange.com/search?q={{.Query}}",
+	})
+	cfg.Abbreviations = &config.AbbreviationConfig{Mode: config.AbbreviationsOn}
+	r := newTestResolver(cfg)
+
+	testCases := []struct {
+		query    string
+		expected Result
+	}{
+		{
+			query: "stacko foo",
+			expected: Result{
+				Query:        "stacko foo",
+				Action:       ActionRedirect,
+				Command:      "stackoverflow",
+				Abbreviation: "stacko",
+				URL:          "https://stackoverflow.com/search?q=foo",
+			},
+		},
+		{
+			query: "gith proj roadmap",
+			expected: Result{
+				Query:        "gith proj roadmap",
+				Action:       ActionRedirect,
+				Command:      "github",
+				Subcommand:   "projects",
+				Abbreviation: "gith",
+				URL:          "https://github.com/projects?q=roadmap",
+			},
+		},
+		{
+			query: "stack foo",
+			expected: Result{
+				Query:        "stack foo",
+				Action:       ActionAmbiguous,
+				Abbreviation: "stack",
+				Candidates: []Candidate{
+					{Command: "stackexchange", Query: "stackexchange foo", Description: "Search Stack Exchange"},
+					{Command: "stackoverflow", Query: "stackoverflow foo", Description: "Search Stack Overflow"},
+				},
+				URL: "https://www.google.com/?q=stack+foo",
+			},
+		},
+		{
+			query: "gh iss bug",
+			expected: Result{
+				Query:        "gh iss bug",
+				Action:       ActionAmbiguous,
+				Command:      "github",
+				Alias:        "gh",
+				Abbreviation: "iss",
+				Candidates: []Candidate{
+					{Command: "github issue-templates", Query: "gh issue-templates bug", Description: "Issue templates"},
+					{Command: "github issues", Query: "gh issues bug", Description: "GitHub issues"},
+				},
+				URL: "https://github.com/search?q=iss+bug",
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		res, err := r.Resolve(tc.query)
+		if err != nil {
+			t.Errorf("Resolve(%q) failed: %v", tc.query, err)
+			continue
+		}
+		if !reflect.DeepEqual(res, tc.expected) {
+			t.Errorf("Resolve(%q) =\n%+v\nexpected\n%+v", tc.query, res, tc.expected)
+		}
+	}
+}
+
+func TestResolve_AbbreviationsOffByDefault(t *testing.T) {
+	r := newTestResolver(newTestConfig())
+
+	// First words that start like a command are searched like any other
+	for _, tc := range []struct{ query, expected string }{
+		{"stack of pancakes", "https://www.google.com/?q=stack+of+pancakes"},
+		{"pul tabs", "https://www.google.com/?q=pul+tabs"},
+		{"git rebase onto", "https://www.google.com/?q=git+rebase+onto"},
+	} {
+		res, err := r.Resolve(tc.query)
+		if err != nil {
+			t.Errorf("Resolve(%q) failed: %v", tc.query, err)
+			continue
+		}
+		if res.Action != ActionRedirect || res.Command != "google" || !res.Fallback || res.Abbreviation != "" || res.URL != tc.expected {
+			t.Errorf("Resolve(%q) = %+v, expected the default search %q", tc.query, res, tc.expected)
+		}
+	}
+}
+
+func TestResolve_Autocorrect(t *testing.T) {
+	cfg := newTestConfig()
+	cfg.DidYouMean = &config.DidYouMeanConfig{Mode: config.DidYouMeanAutocorrect}
+	r := newTestResolver(cfg)
+
+	res, err := r.Resolve("gihtub pr x")
+	if err != nil {
+		t.Fatalf("Resolve failed: %v", err)
+	}
+	if !res.Autocorrected || res.Command != "github" || res.Subcommand != "pr" {
+		t.Errorf("Expected autocorrected github pr, got %+v", res)
+	}
+	if res.URL != "https://github.com/search?type=pullrequests&q=x" {
+		t.Errorf("Unexpected URL %q", res.URL)
+	}
+}
+
+func TestResolveFor_NotifiesObservers(t *testing.T) {
+	var observed []Result
+	var clients []Client
+	observer := ObserverFunc(func(res Result, client Client) {
+		observed = append(observed, res)
+		clients = append(clients, client)
+	})
+	r := newTestResolver(newTestConfig(), observer)
+
+	if _, err := r.Resolve("g test"); err != nil {
+		t.Fatalf("Resolve failed: %v", err)
+	}
+	if len(observed) != 0 {
+		t.Fatal("Expected Resolve not to notify observers")
+	}
+
+	client := Client{UserAgent: "test-agent", RemoteAddr: "127.0.0.1"}
+	res, err := r.ResolveFor("g test", client)
+	if err != nil {
+		t.Fatalf("ResolveFor failed: %v", err)
+	}
+	if len(observed) != 1 || !reflect.DeepEqual(observed[0], res) || clients[0] != client {
+		t.Errorf("Expected observer to see %+v for %+v, got %+v for %+v", res, client, observed, clients)
+	}
+}
+
+func TestResultName(t *testing.T) {
+	testCases := []struct {
+		res   Result
+		name  string
+		usage string
+	}{
+		{Result{Command: "github", Subcommand: "actions runs"}, "github actions runs", "github"},
+		{Result{Command: "github", Subcommand: "pr"}, "github pr", "github"},
+		{Result{Command: "google", FallbackStep: "default-fallback"}, "google", "google"},
+		{Result{FallbackStep: "google-fallback"}, "google-fallback", "google-fallback"},
+		{Result{Pattern: "jira-ticket"}, "jira-ticket", "jira-ticket"},
+		{Result{Navigation: true}, "navigation", "navigation"},
+	}
+
+	for _, tc := range testCases {
+		if name := tc.res.Name(); name != tc.name {
+			t.Errorf("Name() of %+v = %q, expected %q", tc.res, name, tc.name)
+		}
+		if usage := tc.res.UsageCommand(); usage != tc.usage {
+			t.Errorf("UsageCommand() of %+v = %q, expected %q", tc.res, usage, tc.usage)
+		}
+	}
+}
+
+func TestCheckExamples(t *testing.T) {
+	cfg := newTestConfig()
+	cfg.Commands[0].Examples = []config.Example{
+		{Query: "g golang", URL: "https://www.google.com/?q=golang"},
+	}
+	cfg.Commands[len(cfg.Commands)-1].Subcommands[0].Examples = []config.Example{
+		{Query: "gh pr flaky", URL: "https://github.com/search?type=pullrequests&q=flaky"},
+		{Query: "gh issues flaky", URL: "https://github.com/search?type=issues&q=flaky"},
+	}
+	cfg.Commands[2].Examples = []config.Example{
+		{Query: "pulls olion500", URL: "https://github.com/olion500/gopherlol/pulls"},
+	}
+	r := newTestResolver(cfg)
+
+	expected := []ExampleResult{
+		{Command: "github pr", Query: "gh pr flaky", Expected: "https://github.com/search?type=pullrequests&q=flaky", Got: "https://github.com/search?type=pullrequests&q=flaky", Handler: "github pr", Passed: true},
+		{Command: "github pr", Query: "gh issues flaky", Expected: "https://github.com/search?type=issues&q=flaky", Got: "https://github.com/search?q=issues+flaky", Handler: "github"},
+		{Command: "google", Query: "g golang", Expected: "https://www.google.com/?q=golang", Got: "https://www.google.com/?q=golang", Handler: "google", Passed: true},
+		{Command: "pulls", Query: "pulls olion500", Expected: "https://github.com/olion500/gopherlol/pulls", Handler: "pulls", Error: "missing required argument <repo>"},
+	}
+	if results := r.CheckExamples(); !reflect.DeepEqual(results, expected) {
+		t.Errorf("Expected %+v, got %+v", expected, results)
+	}
+}
@@ -1,10 +1,17 @@
 package main
 
 import (
+	"encoding/json"
+	"errors"
+	"flag"
 	"fmt"
 	"github.com/joho/godotenv"
 	"github.com/olion500/gopherlol/internal/analytics"
 	"github.com/olion500/gopherlol/internal/config"
+	"github.com/olion500/gopherlol/internal/resolver"
+	"html"
+	"io"
+	"io/fs"
 	"log"
 	"net/http"
 	"net/url"
@@ -12,116 +19,119 @@ import (
 	"strings"
 )
 
-var (
-	commandRegistry *config.CommandRegistry
-	analyticsSystem *analytics.Analytics
-)
+var analyticsSystem *analytics.Analytics
 
 func handler(w http.ResponseWriter, r *http.Request) {
 	q := r.URL.Query().Get("q")
 
-	// Parse command and arguments
-	parts := strings.SplitN(q, " ", 3)
-	cmdName := strings.ToLower(parts[0])
+	queryResolver := currentResolver()
+	res, err := queryResolver.ResolveFor(q, resolver.Client{UserAgent: r.UserAgent(), RemoteAddr: r.RemoteAddr})
+
+	switch res.Action {
+	case resolver.ActionHelp:
+		generateHelpPage(w, queryResolver.Registry())
+	case resolver.ActionDidYouMean:
+		generateDidYouMeanPage(w, res)
+	case resolver.ActionAmbiguous:
+		generateAmbiguousPage(w, res)
+	case resolver.ActionError:
+		writeResolveError(w, res, err)
+	default:
+		if res.Autocorrected {
+			log.Printf("Autocorrected query %q to command %q", q, res.Command)
+		}
+		http.Redirect(w, r, res.URL, http.StatusSeeOther)
+	}
+}
 
-	// Handle help/list commands
-	if cmdName == "list" || cmdName == "help" {
-		analyticsSystem.LogCommandUsage("help", q, r.UserAgent(), r.RemoteAddr, false, false, "")
-		generateHelpPage(w)
+// logUsage records a resolution in the usage analytics, unless they are off
+func logUsage(res resolver.Result, client resolver.Client) {
+	if analyticsSystem == nil {
 		return
 	}
+	analyticsSystem.LogCommandUsage(res.UsageCommand(), res.Query, client.UserAgent, client.RemoteAddr, res.Fallback, res.Subcommand != "", res.Subcommand)
+}
 
-	// Try to find the command
-	cmd := commandRegistry.FindCommand(cmdName)
-	if cmd == nil {
-		// Command not found => fall back to default command or Google
-		defaultCmd := commandRegistry.GetDefaultCommand()
-		if defaultCmd != nil {
-			// Use default command with full query as search term
-			analyticsSystem.LogCommandUsage(defaultCmd.Name, q, r.UserAgent(), r.RemoteAddr, true, false, "")
-			query := url.QueryEscape(q)
-			targetURL, err := config.ExecuteURL(defaultCmd.URL, query)
-			if err != nil {
-				log.Printf("Error executing default command URL template: %v", err)
-				http.Error(w, "Internal Server Error", http.StatusInternalServerError)
-				return
-			}
-			http.Redirect(w, r, targetURL, http.StatusSeeOther)
-			return
-		} else {
-			// No default command configured => fall back to Google
-			analyticsSystem.LogCommandUsage("google-fallback", q, r.UserAgent(), r.RemoteAddr, true, false, "")
-			fallbackURL := fmt.Sprintf("https://www.google.com/?q=%s", url.QueryEscape(q))
-			http.Redirect(w, r, fallbackURL, http.StatusSeeOther)
-			return
-		}
+// apiResolveHandler runs the same resolution as handler but describes the
+// outcome as JSON instead of redirecting. Usage is not logged.
+func apiResolveHandler(w http.ResponseWriter, r *http.Request) {
+	res, _ := currentResolver().Resolve(r.URL.Query().Get("q"))
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(res); err != nil {
+		log.Printf("Error writing resolution: %v", err)
 	}
+}
 
-	// Check for subcommands
-	var targetURL string
-	var query string
-
-	if len(parts) >= 2 {
-		// Check if second part is a subcommand
-		subCmd := commandRegistry.FindSubcommand(cmdName, parts[1])
-		if subCmd != nil {
-			// Found subcommand, use remaining parts as query
-			if len(parts) >= 3 {
-				query = url.QueryEscape(parts[2])
-			}
-			analyticsSystem.LogCommandUsage(cmd.Name, q, r.UserAgent(), r.RemoteAddr, false, true, subCmd.Name)
-			var err error
-			targetURL, err = config.ExecuteURL(subCmd.URL, query)
-			if err != nil {
-				log.Printf("Error executing subcommand URL template: %v", err)
-				http.Error(w, "Internal Server Error", http.StatusInternalServerError)
-				return
-			}
-		} else {
-			// No subcommand found, treat everything after command as query
-			query = url.QueryEscape(strings.Join(parts[1:], " "))
-			analyticsSystem.LogCommandUsage(cmd.Name, q, r.UserAgent(), r.RemoteAddr, false, false, "")
-			var err error
-			targetURL, err = config.ExecuteURL(cmd.URL, query)
-			if err != nil {
-				log.Printf("Error executing command URL template: %v", err)
-				http.Error(w, "Internal Server Error", http.StatusInternalServerError)
-				return
-			}
-		}
-	} else {
-		// No arguments, just the command
-		if cmd.RequiresQuery {
-			// Command requires query but none provided, fallback to google
-			analyticsSystem.LogCommandUsage("google-fallback", q, r.UserAgent(), r.RemoteAddr, true, false, "")
-			fallbackURL := fmt.Sprintf("https://www.google.com/?q=%s", url.QueryEscape(q))
-			http.Redirect(w, r, fallbackURL, http.StatusSeeOther)
-			return
-		}
-		analyticsSystem.LogCommandUsage(cmd.Name, q, r.UserAgent(), r.RemoteAddr, false, false, "")
-		var err error
-		targetURL, err = config.ExecuteURL(cmd.URL, "")
-		if err != nil {
-			log.Printf("Error executing command URL template: %v", err)
-			http.Error(w, "Internal Server Error", http.StatusInternalServerError)
-			return
+// writeResolveError reports a query that could not be resolved to a URL.
+// Missing arguments are the user's mistake and get a usage message, a
+// failed template is a broken config, and anything else is an error page
+// from the fallback chain.
+func writeResolveError(w http.ResponseWriter, res resolver.Result, err error) {
+	var argErr *config.ArgumentError
+	var flagErr *config.FlagError
+	switch {
+	case err == nil:
+		generateErrorPage(w, http.StatusNotFound, res.Error)
+	case errors.As(err, &argErr):
+		http.Error(w, fmt.Sprintf("%s: %v\nUsage: %s", res.Name(), argErr, res.Usage), http.StatusBadRequest)
+	case errors.As(err, &flagErr):
+		generateFlagErrorPage(w, res, flagErr)
+	default:
+		log.Printf("Error executing URL template for %s: %v", res.Name(), err)
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+	}
+}
+
+// generateFlagErrorPage explains which flag value was not accepted and
+// lists the values that are
+func generateFlagErrorPage(w http.ResponseWriter, res resolver.Result, flagErr *config.FlagError) {
+	var page strings.Builder
+	page.WriteString("<h1>Invalid flag</h1>")
+	page.WriteString(fmt.Sprintf("<p><strong>%s</strong>: %s</p>", html.EscapeString(res.Name()), html.EscapeString(flagErr.Error())))
+	if len(res.Options) > 0 {
+		page.WriteString(fmt.Sprintf("<p>Valid values for <code>-%s</code>:</p><ul>", html.EscapeString(flagErr.Flag.Name)))
+		for _, option := range res.Options {
+			page.WriteString(fmt.Sprintf("<li><code>%s</code></li>", html.EscapeString(option)))
 		}
+		page.WriteString("</ul>")
 	}
+	page.WriteString(`<p><a href="/?q=help">See all commands</a></p>`)
 
-	http.Redirect(w, r, targetURL, http.StatusSeeOther)
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(http.StatusBadRequest)
+	_, _ = fmt.Fprint(w, page.String())
 }
 
-func generateHelpPage(w http.ResponseWriter) {
-	commands := commandRegistry.ListCommands()
+// generateErrorPage renders a short HTML page explaining why a query failed
+func generateErrorPage(w http.ResponseWriter, status int, message string) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(status)
+	_, _ = fmt.Fprintf(w,
+		`<h1>gopherlol</h1><p>%s</p><p><a href="/?q=help">See all commands</a></p>`,
+		html.EscapeString(message),
+	)
+}
+
+func generateHelpPage(w http.ResponseWriter, registry *config.CommandRegistry) {
+	commands := registry.ListCommands()
+
+	// Only name the source layers when there is more than one
+	layers := make(map[string]bool)
+	for _, cmd := range commands {
+		layers[cmd.Source] = true
+	}
 
-	var html strings.Builder
-	html.WriteString("<h1>gopherlol command list</h1>")
-	html.WriteString("<ul>")
+	var page strings.Builder
+	page.WriteString(`<link rel="search" type="application/opensearchdescription+xml" title="gopherlol" href="/opensearch.xml">`)
+	page.WriteString("<h1>gopherlol command list</h1>")
+	page.WriteString("<ul>")
 
 	for _, cmd := range commands {
 		aliases := ""
 		if len(cmd.Aliases) > 0 {
-			aliases = fmt.Sprintf(" (aliases: %s)", strings.Join(cmd.Aliases, ", "))
+			aliases = fmt.Sprintf(" (aliases: %s)", html.EscapeString(strings.Join(cmd.Aliases, ", ")))
 		}
 
 		requiresQuery := ""
@@ -129,77 +139,245 @@ func generateHelpPage(w http.ResponseWriter) {
 			requiresQuery = ", requires query"
 		}
 
-		html.WriteString(fmt.Sprintf(
-			"<li><strong>%s</strong>%s%s - %s</li>",
-			cmd.Name,
+		source := ""
+		if len(layers) > 1 {
+			source = sourceLabel(cmd.Source)
+		}
+
+		page.WriteString(fmt.Sprintf(
+			"<li><strong>%s</strong>%s%s%s - %s%s%s%s</li>",
+			html.EscapeString(cmd.Name),
+			paramUsage(cmd.Params),
 			aliases,
 			requiresQuery,
-			cmd.Description,
+			html.EscapeString(cmd.Description),
+			source,
+			flagList(cmd.Flags),
+			exampleLinks(cmd.Examples),
 		))
 
 		// Show subcommands if any
-		if len(cmd.Subcommands) > 0 {
-			html.WriteString("<ul>")
-			for _, sub := range cmd.Subcommands {
-				subAliases := ""
-				if len(sub.Aliases) > 0 {
-					subAliases = fmt.Sprintf(" (aliases: %s)", strings.Join(sub.Aliases, ", "))
-				}
-				html.WriteString(fmt.Sprintf(
-					"<li><strong>%s %s</strong>%s - %s</li>",
-					cmd.Name,
-					sub.Name,
-					subAliases,
-					sub.Description,
-				))
-			}
-			html.WriteString("</ul>")
-		}
+		writeSubcommandList(&page, cmd.Name, cmd.Subcommands)
 	}
-	html.WriteString("</ul>")
+	page.WriteString("</ul>")
+
+	writePatternList(&page, registry.ListPatterns())
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	_, _ = fmt.Fprint(w, page.String())
+}
+
+// sourceLabel names the layer a command comes from on the help page
+func sourceLabel(layer string) string {
+	return " <em>[" + html.EscapeString(layer) + "]</em>"
+}
+
+// writePatternList renders the patterns that match queries without a
+// command, in the order they are tried
+func writePatternList(page *strings.Builder, patterns []config.Pattern) {
+	if len(patterns) == 0 {
+		return
+	}
+
+	page.WriteString("<h2>Patterns</h2>")
+	page.WriteString("<ul>")
+	for _, pattern := range patterns {
+		page.WriteString(fmt.Sprintf(
+			"<li><strong>%s</strong> <code>%s</code> - %s%s</li>",
+			html.EscapeString(pattern.Label()),
+			html.EscapeString(pattern.Match),
+			html.EscapeString(pattern.Description),
+			exampleLinks(pattern.Examples),
+		))
+	}
+	page.WriteString("</ul>")
+}
+
+// generateDidYouMeanPage offers the commands closest to a misspelled one,
+// each linking to the query retyped with that command, plus a link that
+// searches for the query as typed
+func generateDidYouMeanPage(w http.ResponseWriter, res resolver.Result) {
+	typed := commandWord(res.Query)
+
+	var page strings.Builder
+	page.WriteString("<h1>Did you mean?</h1>")
+	page.WriteString(fmt.Sprintf("<p>There is no command named <strong>%s</strong>.</p>", html.EscapeString(typed)))
+	writeCandidateList(&page, res)
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	_, _ = fmt.Fprint(w, page.String())
+}
+
+// generateAmbiguousPage offers the commands or subcommands an abbreviated
+// name may stand for, plus a link that searches for the query as typed
+func generateAmbiguousPage(w http.ResponseWriter, res resolver.Result) {
+	var page strings.Builder
+	page.WriteString("<h1>Which one?</h1>")
+	page.WriteString(fmt.Sprintf("<p><strong>%s</strong> is short for more than one command.</p>", html.EscapeString(res.Abbreviation)))
+	writeCandidateList(&page, res)
 
 	w.Header().Set("Content-Type", "text/html; charset=utf-8")
 	w.WriteHeader(http.StatusOK)
-	_, _ = fmt.Fprint(w, html.String())
+	_, _ = fmt.Fprint(w, page.String())
+}
+
+// writeCandidateList links to the candidate queries of a result and to its
+// search URL, if any
+func writeCandidateList(page *strings.Builder, res resolver.Result) {
+	page.WriteString("<ul>")
+	for _, c := range res.Candidates {
+		page.WriteString(fmt.Sprintf(
+			`<li><a href="/?q=%s">%s</a> - %s</li>`,
+			url.QueryEscape(c.Query),
+			html.EscapeString(c.Query),
+			html.EscapeString(c.Description),
+		))
+	}
+	page.WriteString("</ul>")
+
+	if res.URL != "" {
+		page.WriteString(fmt.Sprintf(
+			`<p><a href="%s">Search anyway</a> for <em>%s</em></p>`,
+			html.EscapeString(res.URL),
+			html.EscapeString(res.Query),
+		))
+	}
+}
+
+// writeSubcommandList renders a nested list of subcommands, recursing into
+// their own subcommands with the full command path as prefix
+func writeSubcommandList(page *strings.Builder, prefix string, subs []config.Subcommand) {
+	if len(subs) == 0 {
+		return
+	}
+
+	page.WriteString("<ul>")
+	for _, sub := range subs {
+		subAliases := ""
+		if len(sub.Aliases) > 0 {
+			subAliases = fmt.Sprintf(" (aliases: %s)", html.EscapeString(strings.Join(sub.Aliases, ", ")))
+		}
+		page.WriteString(fmt.Sprintf(
+			"<li><strong>%s %s</strong>%s%s - %s%s%s</li>",
+			html.EscapeString(prefix),
+			html.EscapeString(sub.Name),
+			paramUsage(sub.Params),
+			subAliases,
+			html.EscapeString(sub.Description),
+			flagList(sub.Flags),
+			exampleLinks(sub.Examples),
+		))
+		writeSubcommandList(page, prefix+" "+sub.Name, sub.Subcommands)
+	}
+	page.WriteString("</ul>")
+}
+
+// exampleLinks renders a command's examples for the help page, each linking
+// to its query
+func exampleLinks(examples []config.Example) string {
+	if len(examples) == 0 {
+		return ""
+	}
+
+	links := make([]string, len(examples))
+	for i, example := range examples {
+		links[i] = fmt.Sprintf(`<a href="/?q=%s"><code>%s</code></a>`, url.QueryEscape(example.Query), html.EscapeString(example.Query))
+	}
+	return "<br><small>e.g. " + strings.Join(links, ", ") + "</small>"
+}
+
+// paramUsage renders a command's params for the help page
+func paramUsage(params []config.Param) string {
+	if len(params) == 0 {
+		return ""
+	}
+	return " <code>" + html.EscapeString(config.ParamUsage(params)) + "</code>"
+}
+
+// flagList renders a command's flags for the help page, with the
+// description and default of each
+func flagList(flags []config.Flag) string {
+	if len(flags) == 0 {
+		return ""
+	}
+
+	items := make([]string, len(flags))
+	for i, flag := range flags {
+		item := "<code>" + html.EscapeString(flag.Usage()) + "</code>"
+		if flag.Description != "" {
+			item += " " + html.EscapeString(flag.Description)
+		}
+		if flag.Default != "" {
+			item += fmt.Sprintf(" (default <code>%s</code>)", html.EscapeString(flag.Default))
+		}
+		items[i] = item
+	}
+	return "<br><small>flags: " + strings.Join(items, ", ") + "</small>"
 }
 
 func main() {
-	// Load environment variables
-	if err := godotenv.Load(); err != nil {
+	// Load environment variables. Every subcommand reads its defaults from
+	// them, so this happens before dispatching.
+	if err := godotenv.Load(); err != nil && !errors.Is(err, fs.ErrNotExist) {
 		log.Printf("Warning: Could not load .env file: %v", err)
 	}
 
-	// Get port from environment or use default
-	port := os.Getenv("PORT")
-	if port == "" {
-		port = "8080"
-	}
+	os.Exit(run(os.Args[1:], os.Getenv, os.Stdout, os.Stderr))
+}
 
-	// Load configuration
-	configFile := "commands.json"
-	commandConfig, err := config.LoadConfig(configFile)
+// runServe runs the redirect server until it fails. Flags override the
+// environment, including anything set in .env.
+func runServe(args []string, getenv func(string) string, out, errOut io.Writer) int {
+	opts, err := parseServerOptions(args, getenv, errOut)
+	if errors.Is(err, flag.ErrHelp) {
+		return 0
+	}
 	if err != nil {
-		if _, err := os.Stat(configFile); os.IsNotExist(err) {
-			log.Printf("Configuration file '%s' not found.", configFile)
+		log.Printf("Invalid arguments: %v", err)
+		return 2
+	}
+	publicBaseURL = opts.baseURL
+
+	// Initialize analytics system
+	if opts.analytics {
+		analyticsSystem = analytics.NewAnalytics(opts.usageLog)
+	}
+
+	// Load configuration, layering the configured files or the system, team
+	// and user configs
+	reloader := newConfigReloader(opts.configSources(), opts.format)
+	if err := reloader.Reload(); err != nil {
+		if errors.Is(err, errNoConfig) {
+			log.Printf("Configuration file 'commands.json' not found.")
 			log.Printf("Please copy 'commands.json.sample' to 'commands.json' and customize it:")
 			log.Printf("  cp commands.json.sample commands.json")
 			log.Printf("Then edit commands.json to configure your custom commands and URLs.")
 		}
-		log.Fatalf("Failed to load command configuration: %v", err)
+		log.Printf("Failed to load command configuration: %v", err)
+		return 1
 	}
 
-	// Initialize command registry
-	commandRegistry = config.NewCommandRegistry(commandConfig)
-
-	// Initialize analytics system
-	analyticsSystem = analytics.NewAnalytics("usage.log")
-
-	log.Printf("Loaded %d commands from %s", len(commandConfig.Commands), configFile)
+	// Pick up config edits without a restart
+	go reloader.Watch(reloadInterval, nil)
 
 	// Route handlers
 	http.HandleFunc("/", handler)
+	http.HandleFunc("/opensearch.xml", openSearchHandler)
+	http.HandleFunc("/suggest", suggestHandler)
+	http.HandleFunc("/api/resolve", apiResolveHandler)
 
-	log.Printf("Starting server on :%s", port)
-	log.Printf("View analytics: make analytics")
-	log.Fatal(http.ListenAndServe(":"+port, nil))
+	listener, err := listen(opts.listen)
+	if err != nil {
+		log.Printf("Failed to listen on %s: %v", opts.listen, err)
+		return 1
+	}
+
+	log.Printf("Starting server on %s", opts.listen)
+	if opts.analytics {
+		log.Printf("View analytics: gopherlol analytics")
+	}
+	log.Print(http.Serve(listener, nil))
+	return 1
 }
@@ -1,20 +1,32 @@
 package main
 
 import (
+	"encoding/json"
 	"github.com/olion500/gopherlol/internal/analytics"
 	"github.com/olion500/gopherlol/internal/config"
+	"github.com/olion500/gopherlol/internal/resolver"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
 	"os"
+	"path/filepath"
+	"reflect"
 	"strings"
 	"testing"
 )
 
 func setupTestRegistry() {
+	setupTestRegistryWith(newTestConfig())
+}
+
+func setupTestRegistryWith(testConfig *config.CommandConfig) {
 	// Initialize analytics for testing
 	analyticsSystem = analytics.NewAnalytics("test_usage.log")
+	installRegistry(config.NewCommandRegistry(testConfig))
+}
 
-	testConfig := &config.CommandConfig{
+func newTestConfig() *config.CommandConfig {
+	return &config.CommandConfig{
 		Commands: []config.Command{
 			{
 				Name:          "google",
@@ -38,6 +50,35 @@ func setupTestRegistry() {
 				URL:           "https://www.markusdosch.com",
 				RequiresQuery: false,
 			},
+			{
+				Name:        "pulls",
+				Description: "Pull requests of a repository",
+				URL:         "https://github.com/{{.Args.owner}}/{{.Args.repo}}/pulls",
+				Params: []config.Param{
+					{Name: "owner"},
+					{Name: "repo"},
+				},
+			},
+			{
+				Name:        "datadog",
+				Aliases:     []string{"dd"},
+				Description: "Datadog",
+				URL:         "https://app.datadoghq.com",
+				Subcommands: []config.Subcommand{
+					{
+						Name:        "logs",
+						Description: "Datadog logs",
+						URL:         "https://app.datadoghq.com/logs?query={{.Query}}",
+						Subcommands: []config.Subcommand{
+							{
+								Name:        "prod",
+								Description: "Production logs",
+								URL:         "https://app.datadoghq.com/logs?query=env%3Aprod+{{.Query}}",
+							},
+						},
+					},
+				},
+			},
 			{
 				Name:          "github",
 				Aliases:       []string{"gh"},
@@ -55,7 +96,6 @@ func setupTestRegistry() {
 			},
 		},
 	}
-	commandRegistry = config.NewCommandRegistry(testConfig)
 }
 
 func TestHandler_Help(t *testing.T) {
@@ -295,3 +335,482 @@ func TestHandler_FallbackToDefault(t *testing.T) {
 		t.Errorf("Expected Location header %q, got %q", expectedURL, location)
 	}
 }
+
+func TestHandler_LogsUsage(t *testing.T) {
+	setupTestRegistry()
+	logFile := filepath.Join(t.TempDir(), "usage.log")
+	analyticsSystem = analytics.NewAnalytics(logFile)
+
+	for _, q := range []string{"gh%20pr%20flaky", "dd%20logs%20prod%20timeout", "g%20golang", "nonexistent%20query"} {
+		handler(httptest.NewRecorder(), httptest.NewRequest("GET", "/?q="+q, nil))
+	}
+
+	data, err := os.ReadFile(logFile)
+	if err != nil {
+		t.Fatalf("Failed to read usage log: %v", err)
+	}
+	var logged []analytics.CommandUsage
+	for _, line := range strings.Split(strings.TrimSpace(string(data)), "\n") {
+		var usage analytics.CommandUsage
+		if err := json.Unmarshal([]byte(line), &usage); err != nil {
+			t.Fatalf("Failed to parse usage log line %q: %v", line, err)
+		}
+		logged = append(logged, usage)
+	}
+
+	expected := []struct {
+		command      string
+		isDefault    bool
+		isSubcommand bool
+		subcommand   string
+	}{
+		{"github", false, true, "pr"},
+		{"datadog", false, true, "logs prod"},
+		{"google", false, false, ""},
+		{"google", true, false, ""},
+	}
+	if len(logged) != len(expected) {
+		t.Fatalf("Expected %d usage entries, got %+v", len(expected), logged)
+	}
+	for i, e := range expected {
+		got := logged[i]
+		if got.Command != e.command || got.IsDefault != e.isDefault || got.IsSubcommand != e.isSubcommand || got.Subcommand != e.subcommand {
+			t.Errorf("Entry %d: expected %+v, got %+v", i, e, got)
+		}
+	}
+}
+
+func TestHandler_NamedArgs(t *testing.T) {
+	setupTestRegistry()
+
+	req := httptest.NewRequest("GET", "/?q=pulls%20olion500%20gopherlol", nil)
+	w := httptest.NewRecorder()
+
+	handler(w, req)
+
+	if w.Code != http.StatusSeeOther {
+		t.Errorf("Expected status %d, got %d", http.StatusSeeOther, w.Code)
+	}
+
+	location := w.Header().Get("Location")
+	expectedURL := "https://github.com/olion500/gopherlol/pulls"
+	if location != expectedURL {
+		t.Errorf("Expected Location header %q, got %q", expectedURL, location)
+	}
+}
+
+func TestHandler_MissingArgs(t *testing.T) {
+	setupTestRegistry()
+
+	req := httptest.NewRequest("GET", "/?q=pulls%20olion500", nil)
+	w := httptest.NewRecorder()
+
+	handler(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status %d, got %d", http.StatusBadRequest, w.Code)
+	}
+
+	body := w.Body.String()
+	if !strings.Contains(body, "missing required argument <repo>") {
+		t.Errorf("Expected missing argument message, got %q", body)
+	}
+	if !strings.Contains(body, "Usage: pulls <owner> <repo>") {
+		t.Errorf("Expected usage message, got %q", body)
+	}
+}
+
+func TestHandler_NestedSubcommands(t *testing.T) {
+	setupTestRegistry()
+
+	testCases := []struct {
+		query    string
+		expected string
+	}{
+		{"dd%20logs%20prod%20timeout", "https://app.datadoghq.com/logs?query=env%3Aprod+timeout"},
+		{"dd%20logs%20staging%20timeout", "https://app.datadoghq.com/logs?query=staging+timeout"},
+		{"dd%20logs", "https://app.datadoghq.com/logs?query="},
+		{"gh%20pr%20flaky%20test", "https://github.com/search?type=pullrequests&q=flaky+test"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.query, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/?q="+tc.query, nil)
+			w := httptest.NewRecorder()
+
+			handler(w, req)
+
+			if w.Code != http.StatusSeeOther {
+				t.Errorf("Expected status %d, got %d", http.StatusSeeOther, w.Code)
+			}
+
+			location := w.Header().Get("Location")
+			if location != tc.expected {
+				t.Errorf("Expected Location header %q, got %q", tc.expected, location)
+			}
+		})
+	}
+}
+
+func TestHandler_HelpShowsSubcommandTree(t *testing.T) {
+	setupTestRegistry()
+
+	req := httptest.NewRequest("GET", "/?q=help", nil)
+	w := httptest.NewRecorder()
+
+	handler(w, req)
+
+	body := w.Body.String()
+	if !strings.Contains(body, "<strong>datadog logs prod</strong>") {
+		t.Errorf("Expected help page to list nested subcommands, got %s", body)
+	}
+}
+
+func TestHandler_HelpEscapesCommands(t *testing.T) {
+	testConfig := newTestConfig()
+	testConfig.Commands[0].Description = "<script>alert(1)</script>"
+	testConfig.Commands[0].Aliases = []string{"<g>"}
+	testConfig.Commands[5].Subcommands[0].Description = "<b>pulls</b>"
+	setupTestRegistryWith(testConfig)
+
+	req := httptest.NewRequest("GET", "/?q=help", nil)
+	w := httptest.NewRecorder()
+
+	handler(w, req)
+
+	body := w.Body.String()
+	if strings.Contains(body, "<script>") || strings.Contains(body, "<b>") || strings.Contains(body, "<g>") {
+		t.Errorf("Expected help page to escape command fields, got %s", body)
+	}
+	if !strings.Contains(body, "(aliases: &lt;g&gt;), requires query - &lt;script&gt;alert(1)&lt;/script&gt;") {
+		t.Errorf("Expected help page to show the escaped description, got %s", body)
+	}
+	if !strings.Contains(body, "- &lt;b&gt;pulls&lt;/b&gt;") {
+		t.Errorf("Expected help page to show the escaped subcommand description, got %s", body)
+	}
+}
+
+func TestHandler_HelpShowsExamples(t *testing.T) {
+	testConfig := newTestConfig()
+	testConfig.Commands[0].Examples = []config.Example{
+		{Query: "g golang & go", URL: "https://www.google.com/?q=golang+%26+go"},
+	}
+	setupTestRegistryWith(testConfig)
+
+	req := httptest.NewRequest("GET", "/?q=help", nil)
+	w := httptest.NewRecorder()
+
+	handler(w, req)
+
+	body := w.Body.String()
+	if !strings.Contains(body, `e.g. <a href="/?q=g+golang+%26+go"><code>g golang &amp; go</code></a>`) {
+		t.Errorf("Expected help page to link the example, got %s", body)
+	}
+}
+
+func TestHandler_Patterns(t *testing.T) {
+	testConfig := newTestConfig()
+	testConfig.Patterns = []config.Pattern{
+		{Name: "jira-key", Description: "Jira <ticket>", Match: `(?P<key>[A-Z]+-\d+)`, URL: "https://jira.example.com/browse/{{.Args.key}}"},
+	}
+	setupTestRegistryWith(testConfig)
+
+	req := httptest.NewRequest("GET", "/?q=PROJ-1234", nil)
+	w := httptest.NewRecorder()
+	handler(w, req)
+
+	if location := w.Header().Get("Location"); location != "https://jira.example.com/browse/PROJ-1234" {
+		t.Errorf("Expected redirect to the Jira ticket, got %q", location)
+	}
+
+	req = httptest.NewRequest("GET", "/?q=help", nil)
+	w = httptest.NewRecorder()
+	handler(w, req)
+
+	if body := w.Body.String(); !strings.Contains(body, "<li><strong>jira-key</strong> <code>(?P&lt;key&gt;[A-Z]+-\\d+)</code> - Jira &lt;ticket&gt;</li>") {
+		t.Errorf("Expected help page to list the pattern, got %s", body)
+	}
+}
+
+func TestHandler_Navigation(t *testing.T) {
+	testConfig := newTestConfig()
+	testConfig.Navigation = &config.NavigationConfig{Suffixes: []string{"internal"}}
+	setupTestRegistryWith(testConfig)
+
+	tests := []struct {
+		query    string
+		expected string
+	}{
+		{query: "grafana.internal", expected: "https://grafana.internal"},
+		{query: "localhost:3000/debug", expected: "http://localhost:3000/debug"},
+		{query: "http://grafana.internal/?a=b", expected: "http://grafana.internal/?a=b"},
+		{query: "http://example.com/?a=b", expected: "https://www.google.com/?q=http%3A%2F%2Fexample.com%2F%3Fa%3Db"},
+	}
+
+	for _, tt := range tests {
+		req := httptest.NewRequest("GET", "/?q="+url.QueryEscape(tt.query), nil)
+		w := httptest.NewRecorder()
+		handler(w, req)
+
+		if location := w.Header().Get("Location"); location != tt.expected {
+			t.Errorf("Query %q: expected redirect to %q, got %q", tt.query, tt.expected, location)
+		}
+	}
+}
+
+func TestHandler_HelpShowsLayers(t *testing.T) {
+	setupTestRegistry()
+
+	personal := &config.CommandConfig{
+		Commands: []config.Command{{Name: "notes", Description: "My notes", URL: "https://notes.example.com/?q={{.Query}}"}},
+	}
+	merged, _ := config.MergeLayers([]config.Layer{
+		{Name: config.LayerTeam, Config: newTestConfig()},
+		{Name: "notes&more.json", Config: personal},
+	})
+	installRegistry(config.NewCommandRegistry(merged))
+
+	req := httptest.NewRequest("GET", "/?q=help", nil)
+	w := httptest.NewRecorder()
+
+	handler(w, req)
+
+	body := w.Body.String()
+	if !strings.Contains(body, "My notes <em>[notes&amp;more.json]</em>") || !strings.Contains(body, "Search Google <em>[team]</em>") {
+		t.Errorf("Expected help page to name each command's layer, got %s", body)
+	}
+}
+
+func TestHandler_DidYouMeanOffByDefault(t *testing.T) {
+	setupTestRegistry()
+
+	req := httptest.NewRequest("GET", "/?q=stackoverfow%20foo", nil)
+	w := httptest.NewRecorder()
+
+	handler(w, req)
+
+	location := w.Header().Get("Location")
+	expectedURL := "https://www.google.com/?q=stackoverfow+foo"
+	if location != expectedURL {
+		t.Errorf("Expected Location header %q, got %q", expectedURL, location)
+	}
+}
+
+func TestHandler_DidYouMeanSuggest(t *testing.T) {
+	testConfig := newTestConfig()
+	testConfig.DidYouMean = &config.DidYouMeanConfig{Mode: config.DidYouMeanSuggest}
+	setupTestRegistryWith(testConfig)
+
+	req := httptest.NewRequest("GET", "/?q=stackoverfow%20foo", nil)
+	w := httptest.NewRecorder()
+
+	handler(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Errorf("Expected status %d, got %d", http.StatusOK, w.Code)
+	}
+
+	body := w.Body.String()
+	if !strings.Contains(body, `<a href="/?q=stackoverflow+foo">stackoverflow foo</a>`) {
+		t.Errorf("Expected link to corrected query, got %s", body)
+	}
+	if !strings.Contains(body, `<a href="https://www.google.com/?q=stackoverfow+foo">Search anyway</a>`) {
+		t.Errorf("Expected search anyway link, got %s", body)
+	}
+}
+
+func TestHandler_Tokenizes(t *testing.T) {
+	setupTestRegistry()
+
+	tests := []struct {
+		query    string
+		expected string
+	}{
+		{query: "gh  pr x", expected: "https://github.com/search?type=pullrequests&q=x"},
+		{query: " so\ttest", expected: "https://stackoverflow.com/search?q=test"},
+		{query: "so “exact phrase”", expected: "https://stackoverflow.com/search?q=%22exact+phrase%22"},
+	}
+
+	for _, tt := range tests {
+		req := httptest.NewRequest("GET", "/?q="+url.QueryEscape(tt.query), nil)
+		w := httptest.NewRecorder()
+		handler(w, req)
+
+		if location := w.Header().Get("Location"); location != tt.expected {
+			t.Errorf("Query %q: expected redirect to %q, got %q", tt.query, tt.expected, location)
+		}
+	}
+}
+
+func TestHandler_Flags(t *testing.T) {
+	testConfig := newTestConfig()
+	testConfig.Commands = append(testConfig.Commands, config.Command{
+		Name:        "logs",
+		Description: "Search logs",
+		URL:         "https://logs.example.com/?q={{.Query}}&env={{.Flags.env}}",
+		Flags: []config.Flag{
+			{Name: "env", Description: "Environment", Values: []string{"prod", "staging"}, Default: "prod"},
+		},
+	})
+	setupTestRegistryWith(testConfig)
+
+	req := httptest.NewRequest("GET", "/?q="+url.QueryEscape("logs -env staging timeout"), nil)
+	w := httptest.NewRecorder()
+	handler(w, req)
+
+	if location := w.Header().Get("Location"); location != "https://logs.example.com/?q=timeout&env=staging" {
+		t.Errorf("Expected the flag to be stripped from the query, got %q", location)
+	}
+
+	req = httptest.NewRequest("GET", "/?q="+url.QueryEscape("logs -env qa timeout"), nil)
+	w = httptest.NewRecorder()
+	handler(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status %d, got %d", http.StatusBadRequest, w.Code)
+	}
+	body := w.Body.String()
+	for _, expected := range []string{"Invalid flag", "<code>-env</code>", "<li><code>prod</code></li>", "<li><code>staging</code></li>"} {
+		if !strings.Contains(body, expected) {
+			t.Errorf("Expected error page to contain %q, got %s", expected, body)
+		}
+	}
+
+	req = httptest.NewRequest("GET", "/?q=help", nil)
+	w = httptest.NewRecorder()
+	handler(w, req)
+
+	if body := w.Body.String(); !strings.Contains(body, "<br><small>flags: <code>[-env prod|staging]</code> Environment (default <code>prod</code>)</small>") {
+		t.Errorf("Expected help page to list the flags, got %s", body)
+	}
+}
+
+func TestHandler_Abbreviations(t *testing.T) {
+	testConfig := newTestConfig()
+	testConfig.Commands = append(testConfig.Commands, config.Command{
+		Name:        "stackexchange",
+		Description: "Search Stack Exchange",
+		URL:         "https://stackexchange.com/search?q={{.Query}}",
+	})
+	testConfig.Abbreviations = &config.AbbreviationConfig{Mode: config.AbbreviationsOn}
+	setupTestRegistryWith(testConfig)
+
+	req := httptest.NewRequest("GET", "/?q=stacko%20foo", nil)
+	w := httptest.NewRecorder()
+	handler(w, req)
+
+	if location := w.Header().Get("Location"); location != "https://stackoverflow.com/search?q=foo" {
+		t.Errorf("Expected a unique prefix to redirect to its command, got %q", location)
+	}
+
+	req = httptest.NewRequest("GET", "/?q=stac%20foo", nil)
+	w = httptest.NewRecorder()
+	handler(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Errorf("Expected status %d, got %d", http.StatusOK, w.Code)
+	}
+	body := w.Body.String()
+	for _, expected := range []string{
+		"<strong>stac</strong> is short for more than one command",
+		`<a href="/?q=stackexchange+foo">stackexchange foo</a>`,
+		`<a href="/?q=stackoverflow+foo">stackoverflow foo</a>`,
+		`<a href="https://www.google.com/?q=stac+foo">Search anyway</a>`,
+	} {
+		if !strings.Contains(body, expected) {
+			t.Errorf("Expected disambiguation page to contain %q, got %s", expected, body)
+		}
+	}
+}
+
+func TestHandler_DidYouMeanAutocorrect(t *testing.T) {
+	testConfig := newTestConfig()
+	testConfig.DidYouMean = &config.DidYouMeanConfig{Mode: config.DidYouMeanAutocorrect}
+	setupTestRegistryWith(testConfig)
+
+	testCases := []struct {
+		query    string
+		expected string
+	}{
+		// Exactly one candidate => run it
+		{"stackoverfow%20foo", "https://stackoverflow.com/search?q=foo"},
+		{"gihtub%20pr%20x", "https://github.com/search?type=pullrequests&q=x"},
+		// Nothing close => regular fallback
+		{"kubernetes%20pods", "https://www.google.com/?q=kubernetes+pods"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.query, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/?q="+tc.query, nil)
+			w := httptest.NewRecorder()
+
+			handler(w, req)
+
+			if w.Code != http.StatusSeeOther {
+				t.Errorf("Expected status %d, got %d", http.StatusSeeOther, w.Code)
+			}
+
+			location := w.Header().Get("Location")
+			if location != tc.expected {
+				t.Errorf("Expected Location header %q, got %q", tc.expected, location)
+			}
+		})
+	}
+}
+
+func TestHandler_FallbackErrorPage(t *testing.T) {
+	testConfig := newTestConfig()
+	testConfig.Fallback = &config.FallbackConfig{
+		Chain: []config.FallbackStep{
+			{Type: config.FallbackError, Message: "Offline mode: <no> search engine"},
+		},
+	}
+	setupTestRegistryWith(testConfig)
+
+	req := httptest.NewRequest("GET", "/?q=unknown", nil)
+	w := httptest.NewRecorder()
+
+	handler(w, req)
+
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected status %d, got %d", http.StatusNotFound, w.Code)
+	}
+
+	body := w.Body.String()
+	if !strings.Contains(body, "Offline mode: &lt;no&gt; search engine") {
+		t.Errorf("Expected escaped error message, got %s", body)
+	}
+}
+
+func TestAPIResolveHandler(t *testing.T) {
+	setupTestRegistry()
+
+	req := httptest.NewRequest("GET", "/api/resolve?q=gh%20pull%20flaky%20test", nil)
+	w := httptest.NewRecorder()
+
+	apiResolveHandler(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Errorf("Expected status %d, got %d", http.StatusOK, w.Code)
+	}
+	if contentType := w.Header().Get("Content-Type"); contentType != "application/json" {
+		t.Errorf("Expected JSON content type, got %q", contentType)
+	}
+
+	var res resolver.Result
+	if err := json.Unmarshal(w.Body.Bytes(), &res); err != nil {
+		t.Fatalf("Failed to decode resolution: %v", err)
+	}
+
+	expected := resolver.Result{
+		Query:      "gh pull flaky test",
+		Action:     resolver.ActionRedirect,
+		Command:    "github",
+		Subcommand: "pr",
+		Alias:      "gh",
+		URL:        "https://github.com/search?type=pullrequests&q=flaky+test",
+	}
+	if !reflect.DeepEqual(res, expected) {
+		t.Errorf("Expected resolution %+v, got %+v", expected, res)
+	}
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+)
+
+// maxSuggestions limits how many completions the suggest endpoint returns
+const maxSuggestions = 10
+
+// openSearchTemplate is the OpenSearch description document served to browsers
+const openSearchTemplate = `<?xml version="1.0" encoding="UTF-8"?>
+<OpenSearchDescription xmlns="http://a9.com/-/spec/opensearch/1.1/" xmlns:moz="http://www.mozilla.org/2006/browser/search/">
+  <ShortName>gopherlol</ShortName>
+  <Description>gopherlol smart bookmarks</Description>
+  <InputEncoding>UTF-8</InputEncoding>
+  <Url type="text/html" method="get" template="%[1]s/?q={searchTerms}"/>
+  <Url type="application/x-suggestions+json" method="get" template="%[1]s/suggest?q={searchTerms}"/>
+  <moz:SearchForm>%[1]s/?q=help</moz:SearchForm>
+</OpenSearchDescription>
+`
+
+// openSearchHandler serves the OpenSearch description so browsers can add
+// gopherlol as a search engine without typing the URL by hand
+func openSearchHandler(w http.ResponseWriter, r *http.Request) {
+	var escaped strings.Builder
+	_ = xml.EscapeText(&escaped, []byte(baseURL(r)))
+
+	w.Header().Set("Content-Type", "application/opensearchdescription+xml; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	_, _ = fmt.Fprintf(w, openSearchTemplate, escaped.String())
+}
+
+// suggestHandler answers browser omnibox suggestion requests using the
+// OpenSearch suggestions JSON format: [query, completions, descriptions]
+func suggestHandler(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query().Get("q")
+
+	suggestions := currentRegistry().Suggest(q)
+	if len(suggestions) > maxSuggestions {
+		suggestions = suggestions[:maxSuggestions]
+	}
+
+	completions := make([]string, 0, len(suggestions))
+	descriptions := make([]string, 0, len(suggestions))
+	for _, s := range suggestions {
+		completions = append(completions, s.Completion)
+		descriptions = append(descriptions, s.Description)
+	}
+
+	w.Header().Set("Content-Type", "application/x-suggestions+json; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode([]interface{}{q, completions, descriptions}); err != nil {
+		log.Printf("Error writing suggestions: %v", err)
+	}
+}
+
+// baseURL returns the externally visible server URL: the configured base
+// URL, or else one reconstructed from the request
+func baseURL(r *http.Request) string {
+	if publicBaseURL != "" {
+		return publicBaseURL
+	}
+
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+	if proto := r.Header.Get("X-Forwarded-Proto"); proto != "" {
+		scheme = proto
+	}
+	return scheme + "://" + r.Host
+}
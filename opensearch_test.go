@@ -0,0 +1,121 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestOpenSearchHandler(t *testing.T) {
+	req := httptest.NewRequest("GET", "http://gl.example:8080/opensearch.xml", nil)
+	w := httptest.NewRecorder()
+
+	openSearchHandler(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Errorf("Expected status %d, got %d", http.StatusOK, w.Code)
+	}
+
+	contentType := w.Header().Get("Content-Type")
+	if !strings.Contains(contentType, "application/opensearchdescription+xml") {
+		t.Errorf("Expected OpenSearch content type, got %q", contentType)
+	}
+
+	body := w.Body.String()
+	if !strings.Contains(body, `template="http://gl.example:8080/?q={searchTerms}"`) {
+		t.Errorf("Expected search URL template in description, got %s", body)
+	}
+	if !strings.Contains(body, `template="http://gl.example:8080/suggest?q={searchTerms}"`) {
+		t.Errorf("Expected suggestion URL template in description, got %s", body)
+	}
+}
+
+func TestOpenSearchHandler_BaseURL(t *testing.T) {
+	publicBaseURL = "https://gl.example.com"
+	defer func() { publicBaseURL = "" }()
+
+	req := httptest.NewRequest("GET", "http://127.0.0.1:8080/opensearch.xml", nil)
+	w := httptest.NewRecorder()
+
+	openSearchHandler(w, req)
+
+	if body := w.Body.String(); !strings.Contains(body, `template="https://gl.example.com/?q={searchTerms}"`) {
+		t.Errorf("Expected the configured base URL in description, got %s", body)
+	}
+}
+
+func TestSuggestHandler(t *testing.T) {
+	setupTestRegistry()
+
+	testCases := []struct {
+		query        string
+		completions  []string
+		descriptions []string
+	}{
+		{
+			query:        "st",
+			completions:  []string{"stackoverflow", "stack"},
+			descriptions: []string{"Search Stack Overflow", "Search Stack Overflow"},
+		},
+		{
+			query:        "gh",
+			completions:  []string{"gh", "gh pr", "gh pull"},
+			descriptions: []string{"GitHub operations", "GitHub pull requests", "GitHub pull requests"},
+		},
+		{
+			query:        "gh p",
+			completions:  []string{"gh pr", "gh pull"},
+			descriptions: []string{"GitHub pull requests", "GitHub pull requests"},
+		},
+		{
+			query:        "zzz",
+			completions:  []string{},
+			descriptions: []string{},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.query, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/suggest?q="+strings.ReplaceAll(tc.query, " ", "%20"), nil)
+			w := httptest.NewRecorder()
+
+			suggestHandler(w, req)
+
+			if w.Code != http.StatusOK {
+				t.Fatalf("Expected status %d, got %d", http.StatusOK, w.Code)
+			}
+
+			var response []interface{}
+			if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+				t.Fatalf("Failed to decode suggestions: %v", err)
+			}
+			if len(response) != 3 {
+				t.Fatalf("Expected 3 elements in response, got %d", len(response))
+			}
+			if response[0] != tc.query {
+				t.Errorf("Expected echoed query %q, got %v", tc.query, response[0])
+			}
+			assertStrings(t, "completions", response[1], tc.completions)
+			assertStrings(t, "descriptions", response[2], tc.descriptions)
+		})
+	}
+}
+
+func assertStrings(t *testing.T, name string, got interface{}, expected []string) {
+	t.Helper()
+
+	list, ok := got.([]interface{})
+	if !ok {
+		t.Fatalf("Expected %s to be a list, got %T", name, got)
+	}
+	if len(list) != len(expected) {
+		t.Fatalf("Expected %s %v, got %v", name, expected, list)
+	}
+	for i := range expected {
+		if list[i] != expected[i] {
+			t.Errorf("Expected %s[%d] = %q, got %v", name, i, expected[i], list[i])
+		}
+	}
+}
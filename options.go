@@ -0,0 +1,138 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"strings"
+	"syscall"
+)
+
+// publicBaseURL overrides the base URL reconstructed from requests, for
+// servers behind a proxy that rewrites the host
+var publicBaseURL string
+
+// serverOptions are the settings for running the server
+type serverOptions struct {
+	configs   []string
+	format    string
+	listen    string
+	usageLog  string
+	analytics bool
+	baseURL   string
+}
+
+// stringList is a flag that can be given more than once
+type stringList []string
+
+func (l *stringList) String() string {
+	return strings.Join(*l, ",")
+}
+
+func (l *stringList) Set(value string) error {
+	*l = append(*l, value)
+	return nil
+}
+
+// onOff is a boolean flag that also accepts on and off
+type onOff bool
+
+func (b *onOff) String() string {
+	if *b {
+		return "on"
+	}
+	return "off"
+}
+
+func (b *onOff) Set(value string) error {
+	switch strings.ToLower(value) {
+	case "on", "true", "1", "yes":
+		*b = true
+	case "off", "false", "0", "no":
+		*b = false
+	default:
+		return fmt.Errorf("want on or off, got %q", value)
+	}
+	return nil
+}
+
+func (b *onOff) IsBoolFlag() bool {
+	return true
+}
+
+// parseServerOptions reads the server settings from flags, falling back to
+// GOPHERLOL_* environment variables and then to the defaults. PORT is still
+// honoured when no listen address is given.
+func parseServerOptions(args []string, getenv func(string) string, errOut io.Writer) (serverOptions, error) {
+	opts := serverOptions{
+		listen:    ":8080",
+		usageLog:  "usage.log",
+		analytics: true,
+		baseURL:   getenv("GOPHERLOL_BASE_URL"),
+	}
+	if port := getenv("PORT"); port != "" {
+		opts.listen = ":" + port
+	}
+	if listen := getenv("GOPHERLOL_LISTEN"); listen != "" {
+		opts.listen = listen
+	}
+	if usageLog := getenv("GOPHERLOL_USAGE_LOG"); usageLog != "" {
+		opts.usageLog = usageLog
+	}
+	analytics := onOff(true)
+	if value := getenv("GOPHERLOL_ANALYTICS"); value != "" {
+		if err := analytics.Set(value); err != nil {
+			return opts, fmt.Errorf("GOPHERLOL_ANALYTICS: %w", err)
+		}
+	}
+
+	flags := flag.NewFlagSet("serve", flag.ContinueOnError)
+	flags.SetOutput(errOut)
+	configFlags := addConfigFlags(flags, getenv)
+	flags.StringVar(&opts.listen, "listen", opts.listen, "address to listen on, host:port or unix:/path/to/socket (env GOPHERLOL_LISTEN)")
+	flags.StringVar(&opts.usageLog, "usage-log", opts.usageLog, "file usage analytics are written to (env GOPHERLOL_USAGE_LOG)")
+	flags.Var(&analytics, "analytics", "record usage analytics, on or off (env GOPHERLOL_ANALYTICS)")
+	flags.StringVar(&opts.baseURL, "base-url", opts.baseURL, "public URL of the server, used in the OpenSearch description (env GOPHERLOL_BASE_URL)")
+	if err := flags.Parse(args); err != nil {
+		return opts, err
+	}
+	if flags.NArg() > 0 {
+		return opts, fmt.Errorf("unexpected argument %q", flags.Arg(0))
+	}
+
+	opts.configs = configFlags.configs()
+	opts.format = configFlags.format
+	opts.analytics = bool(analytics)
+	opts.baseURL = strings.TrimSuffix(opts.baseURL, "/")
+
+	return opts, nil
+}
+
+// configSources returns the layers to load: the configured files, or the
+// default layers when none are named
+func (o serverOptions) configSources() []configSource {
+	return configSources(o.configs)
+}
+
+// listen opens the server's listener. A unix:/path address creates a Unix
+// socket, replacing a stale one left by a previous run but not one a
+// running server still accepts connections on.
+func listen(address string) (net.Listener, error) {
+	if path, ok := strings.CutPrefix(address, "unix:"); ok {
+		if info, err := os.Stat(path); err == nil && info.Mode()&os.ModeSocket != 0 {
+			conn, err := net.Dial("unix", path)
+			if err == nil {
+				_ = conn.Close()
+			}
+			if !errors.Is(err, syscall.ECONNREFUSED) {
+				return nil, fmt.Errorf("listen unix %s: %w", path, syscall.EADDRINUSE)
+			}
+			_ = os.Remove(path)
+		}
+		return net.Listen("unix", path)
+	}
+	return net.Listen("tcp", address)
+}
@@ -0,0 +1,201 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"reflect"
+	"syscall"
+	"testing"
+)
+
+// envFunc returns a getenv over a fixed set of variables
+func envFunc(env map[string]string) func(string) string {
+	return func(key string) string {
+		return env[key]
+	}
+}
+
+func TestParseServerOptions(t *testing.T) {
+	tests := []struct {
+		name     string
+		args     []string
+		env      map[string]string
+		expected serverOptions
+	}{
+		{
+			name:     "defaults",
+			expected: serverOptions{listen: ":8080", usageLog: "usage.log", analytics: true},
+		},
+		{
+			name:     "legacy PORT",
+			env:      map[string]string{"PORT": "9000"},
+			expected: serverOptions{listen: ":9000", usageLog: "usage.log", analytics: true},
+		},
+		{
+			name: "environment",
+			env: map[string]string{
+				"PORT":                "9000",
+				"GOPHERLOL_LISTEN":    "127.0.0.1:8081",
+				"GOPHERLOL_CONFIG":    "/etc/team.json,/home/me/mine.yaml",
+				"GOPHERLOL_USAGE_LOG": "/var/log/gopherlol/usage.log",
+				"GOPHERLOL_ANALYTICS": "off",
+				"GOPHERLOL_BASE_URL":  "https://gl.example.com/",
+			},
+			expected: serverOptions{
+				configs:  []string{"/etc/team.json", "/home/me/mine.yaml"},
+				listen:   "127.0.0.1:8081",
+				usageLog: "/var/log/gopherlol/usage.log",
+				baseURL:  "https://gl.example.com",
+			},
+		},
+		{
+			name: "flags override environment",
+			args: []string{
+				"-config", "team.json", "-config", "https://config.example.com/commands.json",
+				"-listen", "unix:/run/gopherlol.sock",
+				"-usage-log", "u.log",
+				"-analytics",
+				"-base-url", "https://gl.internal",
+			},
+			env: map[string]string{
+				"GOPHERLOL_LISTEN":    "127.0.0.1:8081",
+				"GOPHERLOL_CONFIG":    "/etc/team.json",
+				"GOPHERLOL_ANALYTICS": "off",
+			},
+			expected: serverOptions{
+				configs:   []string{"team.json", "https://config.example.com/commands.json"},
+				listen:    "unix:/run/gopherlol.sock",
+				usageLog:  "u.log",
+				analytics: true,
+				baseURL:   "https://gl.internal",
+			},
+		},
+		{
+			name:     "analytics off",
+			args:     []string{"-analytics=off"},
+			expected: serverOptions{listen: ":8080", usageLog: "usage.log"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts, err := parseServerOptions(tt.args, envFunc(tt.env), &bytes.Buffer{})
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(opts, tt.expected) {
+				t.Errorf("Expected %+v, got %+v", tt.expected, opts)
+			}
+		})
+	}
+}
+
+func TestParseServerOptions_Errors(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		env  map[string]string
+	}{
+		{"bad analytics flag", []string{"-analytics=maybe"}, nil},
+		{"bad analytics env", nil, map[string]string{"GOPHERLOL_ANALYTICS": "sometimes"}},
+		{"unknown flag", []string{"-port", "80"}, nil},
+		{"stray argument", []string{"serve"}, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := parseServerOptions(tt.args, envFunc(tt.env), &bytes.Buffer{}); err == nil {
+				t.Error("Expected an error")
+			}
+		})
+	}
+
+	if _, err := parseServerOptions([]string{"-h"}, envFunc(nil), &bytes.Buffer{}); !errors.Is(err, flag.ErrHelp) {
+		t.Errorf("Expected flag.ErrHelp for -h, got %v", err)
+	}
+}
+
+func TestServerOptions_ConfigSources(t *testing.T) {
+	defaults := serverOptions{}.configSources()
+	if len(defaults) < 2 || defaults[0].Layer != "system" || defaults[1].Layer != "team" {
+		t.Errorf("Expected the default layers, got %+v", defaults)
+	}
+
+	sources := serverOptions{configs: []string{"a.json", "b.yaml"}}.configSources()
+	if len(sources) != 2 || sources[1].Layer != "b.yaml" || sources[1].Optional {
+		t.Errorf("Expected one required layer per config, got %+v", sources)
+	}
+}
+
+func TestListen_Unix(t *testing.T) {
+	socket := filepath.Join(t.TempDir(), "gopherlol.sock")
+
+	for i := 0; i < 2; i++ {
+		// The second round replaces the socket left behind by the first
+		listener, err := listen("unix:" + socket)
+		if err != nil {
+			t.Fatalf("Failed to listen: %v", err)
+		}
+		if listener.Addr().Network() != "unix" {
+			t.Errorf("Expected a unix socket, got %s", listener.Addr().Network())
+		}
+		if unixListener, ok := listener.(interface{ SetUnlinkOnClose(bool) }); ok {
+			unixListener.SetUnlinkOnClose(false)
+		}
+		_ = listener.Close()
+	}
+
+	if _, err := os.Stat(socket); err != nil {
+		t.Fatalf("Expected the stale socket to remain for the test, got %v", err)
+	}
+}
+
+func TestListen_UnixInUse(t *testing.T) {
+	socket := filepath.Join(t.TempDir(), "gopherlol.sock")
+
+	running, err := listen("unix:" + socket)
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	defer func() { _ = running.Close() }()
+
+	if listener, err := listen("unix:" + socket); !errors.Is(err, syscall.EADDRINUSE) {
+		if listener != nil {
+			_ = listener.Close()
+		}
+		t.Fatalf("Expected address in use, got %v", err)
+	}
+
+	// The running server keeps its socket
+	conn, err := net.Dial("unix", socket)
+	if err != nil {
+		t.Fatalf("Expected the running server to stay reachable, got %v", err)
+	}
+	_ = conn.Close()
+}
+
+func TestListen_TCP(t *testing.T) {
+	setupTestRegistry()
+
+	listener, err := listen("127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	defer func() { _ = listener.Close() }()
+
+	go func() { _ = http.Serve(listener, http.HandlerFunc(apiResolveHandler)) }()
+
+	resp, err := http.Get("http://" + listener.Addr().String() + "/api/resolve?q=so+gopher")
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("Expected 200, got %d", resp.StatusCode)
+	}
+}
@@ -0,0 +1,216 @@
+package main
+
+import (
+	"github.com/olion500/gopherlol/internal/config"
+	"github.com/olion500/gopherlol/internal/resolver"
+	"log"
+	"os"
+	"os/signal"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+)
+
+// reloadInterval is how often the config file is checked for changes
+const reloadInterval = 2 * time.Second
+
+// remotePollInterval is how often remote configs are checked for changes,
+// kept longer than reloadInterval to spare the server
+const remotePollInterval = time.Minute
+
+// activeResolver holds the resolver, and through it the registry, that
+// requests are served from. It is replaced as a whole on reload so a request
+// never sees a partially built registry.
+var activeResolver atomic.Pointer[resolver.Resolver]
+
+// currentResolver returns the resolver serving requests
+func currentResolver() *resolver.Resolver {
+	return activeResolver.Load()
+}
+
+// currentRegistry returns the registry serving requests
+func currentRegistry() *config.CommandRegistry {
+	return currentResolver().Registry()
+}
+
+// installRegistry starts serving requests from registry
+func installRegistry(registry *config.CommandRegistry) {
+	activeResolver.Store(resolver.New(registry, resolver.ObserverFunc(logUsage)))
+}
+
+// fileState is what the reloader remembers about a config file to notice
+// edits; a missing file has the zero state
+type fileState struct {
+	modTime time.Time
+	size    int64
+}
+
+// configReloader rebuilds the registry when a config file changes on disk
+// or the process receives SIGHUP. Every candidate file of every layer is
+// watched, so creating a personal config is picked up as well, along with
+// the included files and fragment directories of the last load. An invalid
+// config is logged and ignored, so the previous registry keeps serving.
+type configReloader struct {
+	sources        []configSource
+	format         string
+	remoteInterval time.Duration
+
+	mu         sync.Mutex
+	files      map[string]fileState
+	remotePoll time.Time
+}
+
+// newConfigReloader creates a reloader for sources, read in format or in the
+// format of each file's extension when format is empty. Nothing is loaded
+// until the first call to Reload.
+func newConfigReloader(sources []configSource, format string) *configReloader {
+	return &configReloader{sources: sources, format: format, remoteInterval: remotePollInterval}
+}
+
+// watchedFiles lists the candidate files of every source and the files
+// read by the last load
+func (c *configReloader) watchedFiles() []string {
+	var files []string
+	for _, source := range c.sources {
+		files = append(files, source.Files...)
+	}
+	for file := range c.files {
+		files = append(files, file)
+	}
+	return files
+}
+
+// snapshot records the state of files. URLs are recorded without state;
+// they are polled instead.
+func snapshot(files []string) map[string]fileState {
+	states := make(map[string]fileState)
+	for _, file := range files {
+		if config.IsRemote(file) {
+			states[file] = fileState{}
+		} else if info, err := os.Stat(file); err == nil {
+			states[file] = fileState{modTime: info.ModTime(), size: info.Size()}
+		} else {
+			states[file] = fileState{}
+		}
+	}
+	return states
+}
+
+// Reload loads the config sources and swaps in the new registry if it is valid
+func (c *configReloader) Reload() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	// Record the files before reading them so edits made during the load
+	// are noticed on the next poll
+	files := snapshot(c.watchedFiles())
+	c.remotePoll = time.Now()
+
+	registry, loaded, err := loadRegistry(c.sources, c.format, log.Default(), true)
+	for file, state := range snapshot(loaded.Files) {
+		if _, known := files[file]; !known {
+			files[file] = state
+		}
+	}
+	c.files = files
+	if err != nil {
+		return err
+	}
+
+	installRegistry(registry)
+	log.Printf("Loaded %d commands from %s", len(loaded.Config.Commands), describeSources(c.sources))
+	return nil
+}
+
+// changed reports whether any watched file differs from the last load.
+// Remote configs are asked for changes once per remote interval, without
+// holding the lock so a slow server does not hold up a reload.
+func (c *configReloader) changed() bool {
+	c.mu.Lock()
+	pollRemote := time.Since(c.remotePoll) >= c.remoteInterval
+	if pollRemote {
+		c.remotePoll = time.Now()
+	}
+
+	changed := false
+	remote := make(map[string]string) // format of each remote config
+	for file, state := range snapshot(c.watchedFiles()) {
+		if config.IsRemote(file) {
+			if pollRemote {
+				remote[file] = c.remoteFormat(file)
+			}
+			continue
+		}
+
+		previous := c.files[file]
+		if !state.modTime.Equal(previous.modTime) || state.size != previous.size {
+			changed = true
+		}
+	}
+	c.mu.Unlock()
+
+	if !pollRemote {
+		return changed
+	}
+	for file, format := range remote {
+		remoteChanged, err := config.PollRemote(file, format)
+		if err != nil {
+			log.Printf("Warning: %v", err)
+		}
+		changed = changed || remoteChanged
+	}
+
+	// The interval starts once the servers have answered
+	c.mu.Lock()
+	c.remotePoll = time.Now()
+	c.mu.Unlock()
+	return changed
+}
+
+// remoteFormat returns the format a remote config is read in: the
+// reloader's format for the configs it was given, or "" for the extension
+// to decide, as for included files
+func (c *configReloader) remoteFormat(file string) string {
+	if c.format == "" {
+		return ""
+	}
+	for _, source := range c.sources {
+		if slices.Contains(source.Files, file) {
+			// An unknown format fails the load instead
+			format, _ := config.ParseFormat(c.format)
+			return format
+		}
+	}
+	return ""
+}
+
+// Watch polls the config files and listens for SIGHUP, reloading on either,
+// until stop is closed
+func (c *configReloader) Watch(interval time.Duration, stop <-chan struct{}) {
+	hangup := make(chan os.Signal, 1)
+	signal.Notify(hangup, syscall.SIGHUP)
+	defer signal.Stop(hangup)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-hangup:
+			log.Printf("Received SIGHUP, reloading configuration")
+		case <-ticker.C:
+			if !c.changed() {
+				continue
+			}
+			log.Printf("Detected configuration change, reloading")
+		}
+
+		if err := c.Reload(); err != nil {
+			log.Printf("Keeping previous configuration: %v", err)
+		}
+	}
+}
@@ -0,0 +1,308 @@
+package main
+
+import (
+	"fmt"
+	"github.com/olion500/gopherlol/internal/config"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+const (
+	reloadConfigV1 = `{"commands": [{"name": "google", "url": "https://www.google.com/?q={{.Query}}", "default": true}]}`
+	reloadConfigV2 = `{"commands": [{"name": "google", "url": "https://www.google.com/?q={{.Query}}", "default": true}, {"name": "ddg", "url": "https://duckduckgo.com/?q={{.Query}}"}]}`
+)
+
+// setupReloadTest writes content to a temporary config and serves from it
+func setupReloadTest(t *testing.T, content string) (string, *configReloader) {
+	t.Helper()
+	setupTestRegistry()
+
+	path := filepath.Join(t.TempDir(), "commands.json")
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+
+	reloader := newConfigReloader(fileSources([]string{path}), "")
+	if err := reloader.Reload(); err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+	return path, reloader
+}
+
+// rewriteConfig replaces a config file and moves its mtime forward so the
+// change is visible even on filesystems with coarse timestamps
+func rewriteConfig(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+	later := time.Now().Add(time.Minute)
+	if err := os.Chtimes(path, later, later); err != nil {
+		t.Fatalf("Failed to touch config: %v", err)
+	}
+}
+
+func TestConfigReloader_Reload(t *testing.T) {
+	path, reloader := setupReloadTest(t, reloadConfigV1)
+
+	if currentRegistry().FindCommand("ddg") != nil {
+		t.Fatal("Expected ddg to be missing before reload")
+	}
+
+	rewriteConfig(t, path, reloadConfigV2)
+	if !reloader.changed() {
+		t.Fatal("Expected the rewritten config to be detected")
+	}
+	if err := reloader.Reload(); err != nil {
+		t.Fatalf("Expected reload to succeed, got %v", err)
+	}
+
+	if currentRegistry().FindCommand("ddg") == nil {
+		t.Error("Expected ddg after reload")
+	}
+	if reloader.changed() {
+		t.Error("Expected no pending change after reload")
+	}
+}
+
+func TestConfigReloader_KeepsRegistryOnInvalidConfig(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+	}{
+		{"malformed JSON", `{"commands": [`},
+		{"broken template", `{"commands": [{"name": "ddg", "url": "https://duckduckgo.com/?q={{.Query"}]}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path, reloader := setupReloadTest(t, reloadConfigV1)
+			before := currentRegistry()
+
+			rewriteConfig(t, path, tt.content)
+			if err := reloader.Reload(); err == nil {
+				t.Fatal("Expected reload to fail")
+			}
+
+			if currentRegistry() != before {
+				t.Error("Expected the previous registry to keep serving")
+			}
+			if reloader.changed() {
+				t.Error("Expected the invalid file not to be retried until it changes again")
+			}
+		})
+	}
+}
+
+func TestConfigReloader_Watch(t *testing.T) {
+	path, reloader := setupReloadTest(t, reloadConfigV1)
+
+	stop := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		reloader.Watch(10*time.Millisecond, stop)
+	}()
+	defer func() {
+		close(stop)
+		wg.Wait()
+	}()
+
+	rewriteConfig(t, path, reloadConfigV2)
+
+	deadline := time.Now().Add(2 * time.Second)
+	for currentRegistry().FindCommand("ddg") == nil {
+		if time.Now().After(deadline) {
+			t.Fatal("Expected watcher to reload the changed config")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestConfigReloader_ConcurrentRequests(t *testing.T) {
+	path, reloader := setupReloadTest(t, reloadConfigV1)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 200; j++ {
+				if _, err := currentResolver().Resolve("google gopher"); err != nil {
+					t.Errorf("Unexpected resolve error: %v", err)
+					return
+				}
+			}
+		}()
+	}
+
+	for i := 0; i < 20; i++ {
+		content := reloadConfigV1
+		if i%2 == 0 {
+			content = reloadConfigV2
+		}
+		rewriteConfig(t, path, content)
+		if err := reloader.Reload(); err != nil {
+			t.Fatalf("Expected reload to succeed, got %v", err)
+		}
+	}
+	wg.Wait()
+}
+
+func TestConfigReloader_WatchesFragments(t *testing.T) {
+	path, reloader := setupReloadTest(t, reloadConfigV1)
+
+	fragments := filepath.Join(filepath.Dir(path), "commands.d")
+	if err := os.Mkdir(fragments, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	fragment := filepath.Join(fragments, "search.yaml")
+	if err := os.WriteFile(fragment, []byte("commands:\n  - name: ddg\n    url: https://duckduckgo.com/?q={{.Query}}\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if !reloader.changed() {
+		t.Fatal("Expected a new fragment directory to be detected")
+	}
+	if err := reloader.Reload(); err != nil {
+		t.Fatalf("Expected reload to succeed, got %v", err)
+	}
+	if currentRegistry().FindCommand("ddg") == nil {
+		t.Fatal("Expected the fragment's command after reload")
+	}
+
+	rewriteConfig(t, fragment, "commands:\n  - name: google\n    url: https://www.google.com/\n")
+	if !reloader.changed() {
+		t.Fatal("Expected an edited fragment to be detected")
+	}
+	if err := reloader.Reload(); err == nil {
+		t.Fatal("Expected a duplicate command across files to be rejected")
+	}
+	if currentRegistry().FindCommand("ddg") == nil {
+		t.Error("Expected the previous registry to keep serving")
+	}
+}
+
+func TestConfigReloader_PollsRemote(t *testing.T) {
+	setupTestRegistry()
+	cacheDir := config.RemoteCacheDir
+	config.RemoteCacheDir = ""
+	t.Cleanup(func() { config.RemoteCacheDir = cacheDir })
+
+	var body atomic.Value
+	body.Store(reloadConfigV1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		current := body.Load().(string)
+		etag := fmt.Sprintf(`"%d"`, len(current))
+		if r.Header.Get("If-None-Match") == etag {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", etag)
+		_, _ = w.Write([]byte(current))
+	}))
+	defer server.Close()
+
+	reloader := newConfigReloader(fileSources([]string{server.URL + "/commands.json"}), "")
+	if err := reloader.Reload(); err != nil {
+		t.Fatalf("Failed to load remote config: %v", err)
+	}
+	if reloader.changed() {
+		t.Error("Expected no change before the remote interval has passed")
+	}
+
+	reloader.remoteInterval = 0
+	if reloader.changed() {
+		t.Error("Expected an unmodified remote config not to trigger a reload")
+	}
+
+	body.Store(reloadConfigV2)
+	if !reloader.changed() {
+		t.Fatal("Expected the updated remote config to be detected")
+	}
+	if err := reloader.Reload(); err != nil {
+		t.Fatalf("Expected reload to succeed, got %v", err)
+	}
+	if currentRegistry().FindCommand("ddg") == nil {
+		t.Error("Expected ddg from the updated remote config")
+	}
+}
+
+func TestConfigReloader_PollsRemoteWithoutLock(t *testing.T) {
+	setupTestRegistry()
+	cacheDir := config.RemoteCacheDir
+	config.RemoteCacheDir = ""
+	t.Cleanup(func() { config.RemoteCacheDir = cacheDir })
+
+	var slow atomic.Bool
+	polling := make(chan struct{})
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if slow.Load() {
+			close(polling)
+			<-release
+		}
+		_, _ = w.Write([]byte(reloadConfigV1))
+	}))
+	defer server.Close()
+
+	reloader := newConfigReloader(fileSources([]string{server.URL + "/commands.json"}), "")
+	if err := reloader.Reload(); err != nil {
+		t.Fatalf("Failed to load remote config: %v", err)
+	}
+
+	reloader.remoteInterval = 0
+	slow.Store(true)
+	done := make(chan struct{})
+	go func() {
+		reloader.changed()
+		close(done)
+	}()
+
+	<-polling
+	if !reloader.mu.TryLock() {
+		t.Error("Expected the reloader not to be locked while polling a remote config")
+	} else {
+		reloader.mu.Unlock()
+	}
+	close(release)
+	<-done
+}
+
+func TestConfigReloader_PollsRemoteInFormat(t *testing.T) {
+	setupTestRegistry()
+	cacheDir := config.RemoteCacheDir
+	config.RemoteCacheDir = ""
+	t.Cleanup(func() { config.RemoteCacheDir = cacheDir })
+
+	var body atomic.Value
+	body.Store("commands:\n  - name: google\n    url: https://www.google.com/?q={{.Query}}\n")
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(body.Load().(string)))
+	}))
+	defer server.Close()
+
+	reloader := newConfigReloader(fileSources([]string{server.URL + "/config"}), "yaml")
+	if err := reloader.Reload(); err != nil {
+		t.Fatalf("Failed to load remote config: %v", err)
+	}
+
+	reloader.remoteInterval = 0
+	body.Store("commands:\n  - name: google\n    url: https://www.google.com/?q={{.Query}}\n  - name: ddg\n    url: https://duckduckgo.com/?q={{.Query}}\n")
+	if !reloader.changed() {
+		t.Fatal("Expected the updated YAML config to be detected")
+	}
+	if err := reloader.Reload(); err != nil {
+		t.Fatalf("Expected reload to succeed, got %v", err)
+	}
+	if currentRegistry().FindCommand("ddg") == nil {
+		t.Error("Expected ddg from the updated remote config")
+	}
+}
@@ -0,0 +1,218 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"github.com/olion500/gopherlol/internal/config"
+	"github.com/olion500/gopherlol/internal/resolver"
+	"io"
+	"log"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// openBrowser opens url in the desktop's default browser
+var openBrowser = func(url string) error {
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		cmd = exec.Command("open", url)
+	case "windows":
+		cmd = exec.Command("rundll32", "url.dll,FileProtocolHandler", url)
+	default:
+		cmd = exec.Command("xdg-open", url)
+	}
+	return cmd.Run()
+}
+
+// runResolve resolves a query without a server running, with the same
+// config and resolver as the server, and prints the target URL or opens it
+// with -open. Where the server would show a page instead of redirecting, the
+// page is printed as text: the command list for help, the candidates for a
+// misspelled or ambiguous command, or the error. The exit code is 0 when the
+// query resolved to a URL, or was help, and 1 otherwise. Usage is not logged.
+func runResolve(args []string, getenv func(string) string, out, errOut io.Writer) int {
+	flags := flag.NewFlagSet("resolve", flag.ContinueOnError)
+	flags.SetOutput(errOut)
+	configFlags := addConfigFlags(flags, getenv)
+	open := flags.Bool("open", false, "open the URL in the default browser instead of printing it")
+	jsonOutput := flags.Bool("json", false, "print the resolution as JSON, like /api/resolve")
+	flags.Usage = func() {
+		_, _ = fmt.Fprintln(errOut, "Usage: gopherlol resolve [-open] [-json] [-config file] [-format format] <query...>")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if flags.NArg() == 0 {
+		flags.Usage()
+		return 2
+	}
+
+	registry, _, err := loadRegistry(configFlags.sources(), configFlags.format, log.New(errOut, "", 0), false)
+	if err != nil {
+		_, _ = fmt.Fprintf(errOut, "Failed to load command configuration: %v\n", err)
+		return 1
+	}
+
+	res, err := resolver.New(registry).Resolve(joinQuery(flags.Args()))
+
+	if *jsonOutput {
+		encoder := json.NewEncoder(out)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(res); err != nil {
+			_, _ = fmt.Fprintf(errOut, "Error writing resolution: %v\n", err)
+			return 1
+		}
+		if res.Action == resolver.ActionRedirect || res.Action == resolver.ActionHelp {
+			return 0
+		}
+		return 1
+	}
+
+	switch res.Action {
+	case resolver.ActionHelp:
+		writeCommandList(out, registry)
+		return 0
+	case resolver.ActionDidYouMean:
+		writeDidYouMean(errOut, res)
+		return 1
+	case resolver.ActionAmbiguous:
+		writeAmbiguous(errOut, res)
+		return 1
+	case resolver.ActionError:
+		var argErr *config.ArgumentError
+		var flagErr *config.FlagError
+		switch {
+		case errors.As(err, &argErr):
+			_, _ = fmt.Fprintf(errOut, "%s: %v\nUsage: %s\n", res.Name(), argErr, res.Usage)
+		case errors.As(err, &flagErr):
+			_, _ = fmt.Fprintf(errOut, "%s: %v\n", res.Name(), flagErr)
+		default:
+			_, _ = fmt.Fprintf(errOut, "%s\n", res.Error)
+		}
+		return 1
+	}
+
+	if res.Autocorrected {
+		_, _ = fmt.Fprintf(errOut, "Autocorrected to %q\n", res.Command)
+	}
+	if *open {
+		if err := openBrowser(res.URL); err != nil {
+			_, _ = fmt.Fprintf(errOut, "Failed to open %s: %v\n", res.URL, err)
+			return 1
+		}
+		return 0
+	}
+
+	_, _ = fmt.Fprintln(out, res.URL)
+	return 0
+}
+
+// joinQuery rebuilds the query the shell split into arguments. A single
+// argument is taken as the whole query, as typed in the browser. Otherwise
+// each argument stays one word: arguments the tokenizer would split, such
+// as "flaky test" after the shell removed its quotes, are quoted again,
+// while quotes that reached us, as in '"flaky test"', are kept.
+func joinQuery(args []string) string {
+	if len(args) == 1 {
+		return args[0]
+	}
+
+	words := make([]string, len(args))
+	for i, arg := range args {
+		words[i] = quoteWord(arg)
+	}
+	return strings.Join(words, " ")
+}
+
+// quoteWord quotes arg when the tokenizer would split it, choosing the
+// quote that reads back as arg
+func quoteWord(arg string) string {
+	if tokens := config.Tokenize(arg); len(tokens) == 1 && tokens[0].Raw == arg {
+		return arg
+	}
+	for _, quote := range []string{`"`, "'"} {
+		quoted := quote + arg + quote
+		if tokens := config.Tokenize(quoted); len(tokens) == 1 && tokens[0].Value == arg {
+			return quoted
+		}
+	}
+	return arg
+}
+
+// writeCommandList prints the commands of the registry, the text version of
+// the help page
+func writeCommandList(w io.Writer, registry *config.CommandRegistry) {
+	for _, cmd := range registry.ListCommands() {
+		writeCommandLine(w, "", cmd.Name, cmd.Aliases, cmd.Params, cmd.Flags, cmd.Description)
+		writeSubcommandLines(w, "  ", cmd.Name, cmd.Subcommands)
+	}
+	for _, pattern := range registry.ListPatterns() {
+		writeCommandLine(w, "", pattern.Label(), nil, nil, nil, pattern.Description)
+	}
+}
+
+// writeSubcommandLines prints subcommands indented below their command
+func writeSubcommandLines(w io.Writer, indent, prefix string, subs []config.Subcommand) {
+	for _, sub := range subs {
+		writeCommandLine(w, indent, prefix+" "+sub.Name, sub.Aliases, sub.Params, sub.Flags, sub.Description)
+		writeSubcommandLines(w, indent+"  ", prefix+" "+sub.Name, sub.Subcommands)
+	}
+}
+
+// writeCommandLine prints one entry of the command list
+func writeCommandLine(w io.Writer, indent, name string, aliases []string, params []config.Param, flags []config.Flag, description string) {
+	line := indent + name
+	if len(params) > 0 {
+		line += " " + config.ParamUsage(params)
+	}
+	if len(flags) > 0 {
+		line += " " + config.FlagUsage(flags)
+	}
+	if len(aliases) > 0 {
+		line += fmt.Sprintf(" (aliases: %s)", strings.Join(aliases, ", "))
+	}
+	if description != "" {
+		line += " - " + description
+	}
+	_, _ = fmt.Fprintln(w, line)
+}
+
+// writeDidYouMean prints the commands closest to a misspelled one, the text
+// version of the did-you-mean page
+func writeDidYouMean(w io.Writer, res resolver.Result) {
+	typed := commandWord(res.Query)
+	_, _ = fmt.Fprintf(w, "There is no command named %q. Did you mean:\n", typed)
+	writeCandidates(w, res)
+}
+
+// commandWord returns the word of a query that names its command
+func commandWord(query string) string {
+	tokens := config.Tokenize(query)
+	if len(tokens) == 0 {
+		return ""
+	}
+	return tokens[0].Value
+}
+
+// writeAmbiguous prints the commands an abbreviated name may stand for, the
+// text version of the disambiguation page
+func writeAmbiguous(w io.Writer, res resolver.Result) {
+	_, _ = fmt.Fprintf(w, "%q is short for more than one command:\n", res.Abbreviation)
+	writeCandidates(w, res)
+}
+
+// writeCandidates prints the candidate queries of a result and its search
+// URL, if any
+func writeCandidates(w io.Writer, res resolver.Result) {
+	for _, c := range res.Candidates {
+		_, _ = fmt.Fprintf(w, "  %s - %s\n", c.Query, c.Description)
+	}
+	if res.URL != "" {
+		_, _ = fmt.Fprintf(w, "Search anyway: %s\n", res.URL)
+	}
+}
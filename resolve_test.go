@@ -0,0 +1,222 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"github.com/olion500/gopherlol/internal/config"
+	"github.com/olion500/gopherlol/internal/resolver"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+// resolveTestConfig is a config with subcommands, params, a default and
+// did-you-mean suggestions
+const resolveTestConfig = `{
+	"didYouMean": {"mode": "suggest"},
+	"abbreviations": {"mode": "on"},
+	"commands": [
+		{"name": "gh", "description": "GitHub", "url": "https://github.com", "subcommands": [
+			{"name": "pr", "description": "Pull requests", "url": "https://github.com/pulls?q={{.Query}}"}
+		]},
+		{"name": "pulls", "url": "https://github.com/{{.Args.owner}}/{{.Args.repo}}/pulls",
+			"params": [{"name": "owner"}, {"name": "repo"}]},
+		{"name": "stackoverflow", "aliases": ["so"], "description": "Stack Overflow", "url": "https://stackoverflow.com/search?q={{.Query}}"},
+		{"name": "g", "url": "https://google.com/search?q={{.Query}}", "default": true}
+	]
+}`
+
+func TestRunResolve(t *testing.T) {
+	path := writeTestConfig(t, resolveTestConfig)
+
+	tests := []struct {
+		name     string
+		args     []string
+		code     int
+		expected string
+		errOut   string
+	}{
+		{name: "subcommand", args: []string{"gh", "pr", "flaky", "test"}, code: 0, expected: "https://github.com/pulls?q=flaky+test\n"},
+		{name: "whole query", args: []string{"gh pr flaky"}, code: 0, expected: "https://github.com/pulls?q=flaky\n"},
+		{name: "fallback", args: []string{"golang", "generics"}, code: 0, expected: "https://google.com/search?q=golang+generics\n"},
+		{name: "help", args: []string{"help"}, code: 0, expected: "gh - GitHub\n  gh pr - Pull requests\n"},
+		{name: "did you mean", args: []string{"stackoverfow", "foo"}, code: 1, errOut: "stackoverflow foo - Stack Overflow"},
+		{name: "abbreviation", args: []string{"stacko", "foo"}, code: 0, expected: "https://stackoverflow.com/search?q=foo\n"},
+		{name: "missing args", args: []string{"pulls", "olion500"}, code: 1, errOut: "Usage: pulls <owner> <repo>"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out, errOut bytes.Buffer
+			args := append([]string{"-config", path}, tt.args...)
+			if code := runResolve(args, envFunc(nil), &out, &errOut); code != tt.code {
+				t.Fatalf("Expected exit code %d, got %d: %s", tt.code, code, errOut.String())
+			}
+			if !strings.Contains(out.String(), tt.expected) {
+				t.Errorf("Expected output to contain %q, got %q", tt.expected, out.String())
+			}
+			if !strings.Contains(errOut.String(), tt.errOut) {
+				t.Errorf("Expected errors to contain %q, got %q", tt.errOut, errOut.String())
+			}
+		})
+	}
+}
+
+func TestRunResolve_QuotedArgs(t *testing.T) {
+	path := writeTestConfig(t, resolveTestConfig)
+	cfg, err := config.LoadConfig(path)
+	if err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+	setupTestRegistryWith(cfg)
+
+	tests := []struct {
+		args  []string
+		query string
+	}{
+		// The shell removed the quotes of gh pr "flaky test"
+		{args: []string{"gh", "pr", "flaky test"}, query: `gh pr "flaky test"`},
+		// gh pr '"flaky test"' keeps them
+		{args: []string{"gh", "pr", `"flaky test"`}, query: `gh pr "flaky test"`},
+		{args: []string{"pulls", "olion 500", "gopher's lol"}, query: `pulls "olion 500" "gopher's lol"`},
+		{args: []string{"pulls", `say "hi"`, "repo"}, query: `pulls 'say "hi"' repo`},
+		{args: []string{"gh pr \"flaky test\""}, query: `gh pr "flaky test"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/?q="+url.QueryEscape(tt.query), nil)
+			w := httptest.NewRecorder()
+			handler(w, req)
+			location := w.Header().Get("Location")
+			if location == "" {
+				t.Fatalf("Expected %q to redirect, got status %d", tt.query, w.Code)
+			}
+
+			var out, errOut bytes.Buffer
+			args := append([]string{"-config", path}, tt.args...)
+			if code := runResolve(args, envFunc(nil), &out, &errOut); code != 0 {
+				t.Fatalf("Expected exit code 0, got %d: %s", code, errOut.String())
+			}
+			if got := strings.TrimSpace(out.String()); got != location {
+				t.Errorf("Arguments %q resolved to %q, the browser query %q to %q", tt.args, got, tt.query, location)
+			}
+		})
+	}
+}
+
+func TestRunResolve_Ambiguous(t *testing.T) {
+	path := writeTestConfig(t, `{"abbreviations": {"mode": "on"}, "commands": [
+		{"name": "stackoverflow", "description": "Stack Overflow", "url": "https://stackoverflow.com/search?q={{.Query}}"},
+		{"name": "stackexchange", "description": "Stack Exchange", "url": "https://stackexchange.com/search?q={{.Query}}"}
+	]}`)
+
+	var out, errOut bytes.Buffer
+	if code := runResolve([]string{"-config", path, "stack", "foo"}, envFunc(nil), &out, &errOut); code != 1 {
+		t.Fatalf("Expected exit code 1, got %d", code)
+	}
+	expected := "\"stack\" is short for more than one command:\n" +
+		"  stackexchange foo - Stack Exchange\n" +
+		"  stackoverflow foo - Stack Overflow\n" +
+		"Search anyway: https://www.google.com/?q=stack+foo\n"
+	if errOut.String() != expected {
+		t.Errorf("Expected %q, got %q", expected, errOut.String())
+	}
+	if out.Len() != 0 {
+		t.Errorf("Expected no output, got %q", out.String())
+	}
+}
+
+func TestRunResolve_Flags(t *testing.T) {
+	path := writeTestConfig(t, `{"commands": [
+		{"name": "logs", "description": "Logs", "url": "https://logs.example.com/?q={{.Query}}&env={{.Flags.env}}",
+			"flags": [{"name": "env", "values": ["prod", "dev"], "default": "prod"}]}
+	]}`)
+
+	var out, errOut bytes.Buffer
+	if code := runResolve([]string{"-config", path, "logs", "-env", "dev", "timeout"}, envFunc(nil), &out, &errOut); code != 0 {
+		t.Fatalf("Expected exit code 0, got %d: %s", code, errOut.String())
+	}
+	if out.String() != "https://logs.example.com/?q=timeout&env=dev\n" {
+		t.Errorf("Unexpected output %q", out.String())
+	}
+
+	out.Reset()
+	if code := runResolve([]string{"-config", path, "logs", "-env=qa"}, envFunc(nil), &out, &errOut); code != 1 {
+		t.Fatalf("Expected exit code 1, got %d", code)
+	}
+	if !strings.Contains(errOut.String(), `logs: invalid value "qa" for flag -env, must be one of prod, dev`) {
+		t.Errorf("Expected the flag error, got %q", errOut.String())
+	}
+
+	out.Reset()
+	if code := runResolve([]string{"-config", path, "help"}, envFunc(nil), &out, &errOut); code != 0 {
+		t.Fatalf("Expected exit code 0, got %d", code)
+	}
+	if out.String() != "logs [-env prod|dev] - Logs\n" {
+		t.Errorf("Expected the command list to show flags, got %q", out.String())
+	}
+}
+
+func TestRunResolve_Open(t *testing.T) {
+	path := writeTestConfig(t, resolveTestConfig)
+
+	var opened []string
+	original := openBrowser
+	openBrowser = func(url string) error {
+		opened = append(opened, url)
+		return nil
+	}
+	defer func() { openBrowser = original }()
+
+	var out, errOut bytes.Buffer
+	if code := runResolve([]string{"--open", "-config", path, "so", "go", "generics"}, envFunc(nil), &out, &errOut); code != 0 {
+		t.Fatalf("Expected exit code 0, got %d: %s", code, errOut.String())
+	}
+	if len(opened) != 1 || opened[0] != "https://stackoverflow.com/search?q=go+generics" {
+		t.Errorf("Expected the URL to be opened, got %v", opened)
+	}
+	if out.Len() != 0 {
+		t.Errorf("Expected nothing printed when opening, got %q", out.String())
+	}
+
+	openBrowser = func(url string) error { return errors.New("no browser") }
+	if code := runResolve([]string{"-open", "-config", path, "so", "go"}, envFunc(nil), &out, &errOut); code != 1 {
+		t.Errorf("Expected exit code 1 when the browser fails, got %d", code)
+	}
+}
+
+func TestRunResolve_JSON(t *testing.T) {
+	path := writeTestConfig(t, resolveTestConfig)
+
+	var out, errOut bytes.Buffer
+	if code := runResolve([]string{"-json", "-config", path, "gh", "pr", "flaky"}, envFunc(nil), &out, &errOut); code != 0 {
+		t.Fatalf("Expected exit code 0, got %d: %s", code, errOut.String())
+	}
+
+	var res resolver.Result
+	if err := json.Unmarshal(out.Bytes(), &res); err != nil {
+		t.Fatalf("Expected JSON, got %q: %v", out.String(), err)
+	}
+	if res.Command != "gh" || res.Subcommand != "pr" || res.URL != "https://github.com/pulls?q=flaky" {
+		t.Errorf("Unexpected resolution %+v", res)
+	}
+}
+
+func TestRunResolve_Errors(t *testing.T) {
+	var out, errOut bytes.Buffer
+	if code := runResolve(nil, envFunc(nil), &out, &errOut); code != 2 {
+		t.Errorf("Expected exit code 2 without a query, got %d", code)
+	}
+
+	errOut.Reset()
+	missing := writeTestConfig(t, "{}") + ".missing"
+	if code := runResolve([]string{"-config", missing, "g", "x"}, envFunc(nil), &out, &errOut); code != 1 {
+		t.Errorf("Expected exit code 1 for a missing config, got %d", code)
+	}
+	if !strings.Contains(errOut.String(), "Failed to load command configuration") {
+		t.Errorf("Expected a load error, got %q", errOut.String())
+	}
+}
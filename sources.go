@@ -0,0 +1,170 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"github.com/olion500/gopherlol/internal/config"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// configFileNames are the file names tried, in order, in a config directory
+var configFileNames = []string{"commands.json", "commands.jsonc", "commands.yaml", "commands.yml", "commands.toml"}
+
+// errNoConfig is returned when none of the config sources exist
+var errNoConfig = errors.New("no configuration file found")
+
+// configSource is one config layer, read from the first of its candidate
+// files that exists
+type configSource struct {
+	Layer    string
+	Files    []string
+	Optional bool
+}
+
+// path returns the candidate file to read, or "" when none exists. URLs are
+// always read.
+func (s configSource) path() string {
+	for _, file := range s.Files {
+		if config.IsRemote(file) {
+			return file
+		}
+		if _, err := os.Stat(file); err == nil {
+			return file
+		}
+	}
+	return ""
+}
+
+// configDirFiles lists the candidate config files in dir
+func configDirFiles(dir string) []string {
+	files := make([]string, len(configFileNames))
+	for i, name := range configFileNames {
+		files[i] = filepath.Join(dir, name)
+	}
+	return files
+}
+
+// defaultConfigSources returns the standard layers: a system-wide config,
+// the team config in the working directory, and the user's personal config.
+// Any of them may be missing, as long as one exists.
+func defaultConfigSources() []configSource {
+	sources := []configSource{
+		{Layer: config.LayerSystem, Files: configDirFiles("/etc/gopherlol"), Optional: true},
+		{Layer: config.LayerTeam, Files: configDirFiles("."), Optional: true},
+	}
+	if dir, err := os.UserConfigDir(); err == nil {
+		sources = append(sources, configSource{Layer: config.LayerUser, Files: configDirFiles(filepath.Join(dir, "gopherlol")), Optional: true})
+	}
+	return sources
+}
+
+// fileSources turns config files named on the command line into layers,
+// each named after its file
+func fileSources(files []string) []configSource {
+	sources := make([]configSource, len(files))
+	for i, file := range files {
+		sources[i] = configSource{Layer: file, Files: []string{file}}
+	}
+	return sources
+}
+
+// loadedConfig is the result of loading and merging the config sources
+type loadedConfig struct {
+	Config    *config.CommandConfig
+	Conflicts []config.Conflict
+	// Stale lists remote configs that were served from their cached copies
+	Stale []error
+	// Files lists every file, directory and URL that was read, for watching
+	Files []string
+}
+
+// loadConfigFile reads a config file with its includes and fragments, in
+// format or in the format implied by its extension when format is empty
+func loadConfigFile(path, format string) (*config.CommandConfig, []string, error) {
+	if format == "" {
+		return config.LoadConfigFiles(path, config.DetectFormat(path))
+	}
+
+	parsed, err := config.ParseFormat(format)
+	if err != nil {
+		return nil, nil, err
+	}
+	return config.LoadConfigFiles(path, parsed)
+}
+
+// loadLayers reads every config source that exists and merges them in order
+func loadLayers(sources []configSource, format string) (*loadedConfig, error) {
+	var layers []config.Layer
+	var tried []string
+	loaded := &loadedConfig{}
+
+	for _, source := range sources {
+		path := source.path()
+		if path == "" {
+			if !source.Optional {
+				return loaded, fmt.Errorf("%s config: %w", source.Layer, fs.ErrNotExist)
+			}
+			tried = append(tried, source.Files...)
+			continue
+		}
+
+		commandConfig, files, err := loadConfigFile(path, format)
+		loaded.Files = append(loaded.Files, files...)
+		if config.IsStale(err) {
+			loaded.Stale = append(loaded.Stale, err)
+		} else if err != nil {
+			return loaded, err
+		}
+		layers = append(layers, config.Layer{Name: source.Layer, Config: commandConfig})
+	}
+
+	if len(layers) == 0 {
+		return loaded, fmt.Errorf("%w (looked for %s)", errNoConfig, strings.Join(tried, ", "))
+	}
+
+	loaded.Config, loaded.Conflicts = config.MergeLayers(layers)
+	return loaded, nil
+}
+
+// loadRegistry reads and merges the config sources and builds a validated
+// registry from them. Stale remote copies are reported to warnings, as are
+// conflicts between layers if reportConflicts is set. The files read are
+// returned even when loading fails, so a broken include can be watched
+// until it is fixed.
+func loadRegistry(sources []configSource, format string, warnings *log.Logger, reportConflicts bool) (*config.CommandRegistry, *loadedConfig, error) {
+	loaded, err := loadLayers(sources, format)
+	if err != nil {
+		return nil, loaded, err
+	}
+
+	for _, err := range loaded.Stale {
+		warnings.Printf("Warning: %v", err)
+	}
+	if reportConflicts {
+		for _, conflict := range loaded.Conflicts {
+			warnings.Printf("Config conflict: %s", conflict)
+		}
+	}
+
+	registry, err := config.NewValidatedCommandRegistry(loaded.Config)
+	if err != nil {
+		return nil, loaded, fmt.Errorf("invalid command configuration:\n%w", err)
+	}
+
+	return registry, loaded, nil
+}
+
+// describeSources lists the config files currently in use
+func describeSources(sources []configSource) string {
+	var used []string
+	for _, source := range sources {
+		if path := source.path(); path != "" {
+			used = append(used, fmt.Sprintf("%s (%s)", path, source.Layer))
+		}
+	}
+	return strings.Join(used, ", ")
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadLayers(t *testing.T) {
+	dir := t.TempDir()
+	teamDir := filepath.Join(dir, "team")
+	userDir := filepath.Join(dir, "user")
+	for _, d := range []string{teamDir, userDir} {
+		if err := os.Mkdir(d, 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	team := `{"commands": [
+		{"name": "google", "aliases": ["g"], "url": "https://www.google.com/?q={{.Query}}", "default": true},
+		{"name": "jira", "aliases": ["j"], "url": "https://jira.example.com/browse/{{.Query}}"}
+	]}`
+	user := "disable: [jira]\ncommands:\n  - name: gitlab\n    aliases: [g]\n    url: https://gitlab.com/search?search={{.Query}}\n"
+	if err := os.WriteFile(filepath.Join(teamDir, "commands.json"), []byte(team), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(userDir, "commands.yaml"), []byte(user), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	sources := []configSource{
+		{Layer: "system", Files: configDirFiles(filepath.Join(dir, "system")), Optional: true},
+		{Layer: "team", Files: configDirFiles(teamDir), Optional: true},
+		{Layer: "user", Files: configDirFiles(userDir), Optional: true},
+	}
+
+	loaded, err := loadLayers(sources, "")
+	if err != nil {
+		t.Fatalf("Failed to load layers: %v", err)
+	}
+	merged, conflicts := loaded.Config, loaded.Conflicts
+
+	var names []string
+	for _, cmd := range merged.Commands {
+		names = append(names, cmd.Name+"@"+cmd.Source)
+	}
+	if len(names) != 2 || names[0] != "google@team" || names[1] != "gitlab@user" {
+		t.Errorf("Expected google from team and gitlab from user, got %v", names)
+	}
+	if len(conflicts) != 1 || conflicts[0].Key != "g" {
+		t.Errorf("Expected the alias takeover to be reported, got %+v", conflicts)
+	}
+}
+
+func TestLoadLayers_Missing(t *testing.T) {
+	dir := t.TempDir()
+
+	_, err := loadLayers([]configSource{{Layer: "team", Files: configDirFiles(dir), Optional: true}}, "")
+	if !errors.Is(err, errNoConfig) {
+		t.Errorf("Expected errNoConfig, got %v", err)
+	}
+
+	_, err = loadLayers(fileSources([]string{filepath.Join(dir, "missing.json")}), "")
+	if !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("Expected a required file to be reported missing, got %v", err)
+	}
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"github.com/olion500/gopherlol/internal/resolver"
+	"io"
+	"log"
+)
+
+// runTest resolves the examples of every command in the configuration and
+// reports the ones that do not go to their expected URL. It returns 0 when
+// every example passes, 1 when one fails and 2 when the config cannot be
+// loaded.
+func runTest(args []string, getenv func(string) string, out, errOut io.Writer) int {
+	flags := flag.NewFlagSet("test", flag.ContinueOnError)
+	flags.SetOutput(errOut)
+	configFlags := addConfigFlags(flags, getenv)
+	verbose := flags.Bool("v", false, "list passing examples too")
+	jsonOutput := flags.Bool("json", false, "print the results as JSON")
+	flags.Usage = func() {
+		_, _ = fmt.Fprintln(errOut, "Usage: gopherlol test [-v] [-json] [-config file] [-format format]")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if flags.NArg() > 0 {
+		flags.Usage()
+		return 2
+	}
+
+	registry, _, err := loadRegistry(configFlags.sources(), configFlags.format, log.New(errOut, "", 0), false)
+	if err != nil {
+		_, _ = fmt.Fprintf(errOut, "Failed to load command configuration: %v\n", err)
+		return 2
+	}
+
+	results := resolver.New(registry).CheckExamples()
+	failed := 0
+	for _, result := range results {
+		if !result.Passed {
+			failed++
+		}
+	}
+
+	if *jsonOutput {
+		if results == nil {
+			results = []resolver.ExampleResult{}
+		}
+		encoder := json.NewEncoder(out)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(results); err != nil {
+			_, _ = fmt.Fprintf(errOut, "Error writing results: %v\n", err)
+			return 2
+		}
+	} else {
+		for _, result := range results {
+			writeExampleResult(out, result, *verbose)
+		}
+		_, _ = fmt.Fprintf(out, "%d examples, %d failed\n", len(results), failed)
+	}
+
+	if failed > 0 {
+		return 1
+	}
+	return 0
+}
+
+// writeExampleResult prints a failed example with what went wrong, or a
+// passing one when verbose is set
+func writeExampleResult(w io.Writer, result resolver.ExampleResult, verbose bool) {
+	if result.Passed {
+		if verbose {
+			_, _ = fmt.Fprintf(w, "ok   %s: %s\n", result.Command, result.Query)
+		}
+		return
+	}
+
+	_, _ = fmt.Fprintf(w, "FAIL %s: %s\n", result.Command, result.Query)
+	_, _ = fmt.Fprintf(w, "     expected %s\n", result.Expected)
+	switch {
+	case result.Error != "":
+		_, _ = fmt.Fprintf(w, "     got error %s\n", result.Error)
+	case result.Got == "":
+		_, _ = fmt.Fprintf(w, "     got no URL\n")
+	default:
+		_, _ = fmt.Fprintf(w, "     got      %s\n", result.Got)
+	}
+	if result.Handler != "" && result.Handler != result.Command {
+		_, _ = fmt.Fprintf(w, "     resolved by %s\n", result.Handler)
+	}
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/olion500/gopherlol/internal/resolver"
+	"strings"
+	"testing"
+)
+
+// TestSampleConfigExamples checks the examples shipped in the sample config,
+// so the real command definitions are tested as well as hand-built ones
+func TestSampleConfigExamples(t *testing.T) {
+	var out, errOut bytes.Buffer
+	if code := runTest([]string{"-config", "commands.json.sample", "-format", "json"}, envFunc(nil), &out, &errOut); code != 0 {
+		t.Fatalf("Expected the sample examples to pass, got exit code %d:\n%s%s", code, out.String(), errOut.String())
+	}
+	if strings.HasPrefix(out.String(), "0 examples") {
+		t.Error("Expected the sample config to have examples")
+	}
+}
+
+func TestRunTest(t *testing.T) {
+	path := writeTestConfig(t, `{"commands": [
+		{"name": "gh", "url": "https://github.com/search?q={{.Query}}",
+			"examples": [{"query": "gh flaky", "url": "https://github.com/search?q=flaky"}],
+			"subcommands": [
+				{"name": "pr", "url": "https://github.com/pulls?q={{.Query}}",
+					"examples": [{"query": "gh issues flaky", "url": "https://github.com/issues?q=flaky"}]}
+			]}
+	]}`)
+
+	var out, errOut bytes.Buffer
+	if code := runTest([]string{"-config", path}, envFunc(nil), &out, &errOut); code != 1 {
+		t.Fatalf("Expected exit code 1, got %d: %s", code, errOut.String())
+	}
+	for _, expected := range []string{
+		"FAIL gh pr: gh issues flaky",
+		"expected https://github.com/issues?q=flaky",
+		"got      https://github.com/search?q=issues+flaky",
+		"resolved by gh",
+		"2 examples, 1 failed",
+	} {
+		if !strings.Contains(out.String(), expected) {
+			t.Errorf("Expected output to contain %q, got %q", expected, out.String())
+		}
+	}
+	if strings.Contains(out.String(), "ok   gh: gh flaky") {
+		t.Errorf("Expected passing examples to be listed only with -v, got %q", out.String())
+	}
+
+	out.Reset()
+	if code := runTest([]string{"-json", "-config", path}, envFunc(nil), &out, &errOut); code != 1 {
+		t.Fatalf("Expected exit code 1, got %d", code)
+	}
+	var results []resolver.ExampleResult
+	if err := json.Unmarshal(out.Bytes(), &results); err != nil {
+		t.Fatalf("Expected JSON, got %q: %v", out.String(), err)
+	}
+	if len(results) != 2 || !results[0].Passed || results[1].Passed {
+		t.Errorf("Unexpected results %+v", results)
+	}
+}
+
+func TestRunTest_LoadFailure(t *testing.T) {
+	var out, errOut bytes.Buffer
+	missing := writeTestConfig(t, "{}") + ".missing"
+	if code := runTest([]string{"-config", missing}, envFunc(nil), &out, &errOut); code != 2 {
+		t.Errorf("Expected exit code 2, got %d", code)
+	}
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"github.com/olion500/gopherlol/internal/config"
+	"io"
+)
+
+// validateReport is the machine-readable output of the validate command
+type validateReport struct {
+	Files    []string       `json:"files"`
+	Valid    bool           `json:"valid"`
+	Errors   int            `json:"errors"`
+	Warnings int            `json:"warnings"`
+	Issues   []config.Issue `json:"issues"`
+}
+
+// runValidate lints a configuration and writes a JSON report to out. Files
+// named on the command line are merged as layers in order, after any given
+// with -config; without any, the default system, team and user layers are
+// used, and conflicts between layers are reported as warnings. It returns
+// the process exit code: 0 when the config is valid, 1 when it has errors
+// (or warnings with -strict) and 2 when it cannot be loaded at all.
+func runValidate(args []string, getenv func(string) string, out, errOut io.Writer) int {
+	flags := flag.NewFlagSet("validate", flag.ContinueOnError)
+	flags.SetOutput(errOut)
+	configFlags := addConfigFlags(flags, getenv)
+	strict := flags.Bool("strict", false, "treat warnings as errors")
+	flags.Usage = func() {
+		_, _ = fmt.Fprintln(errOut, "Usage: gopherlol validate [-strict] [-config file] [-format format] [config file...]")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	configFlags.files = append(configFlags.files, flags.Args()...)
+	sources := configFlags.sources()
+
+	report := validateReport{Files: []string{}, Issues: []config.Issue{}}
+	for _, source := range sources {
+		if path := source.path(); path != "" {
+			report.Files = append(report.Files, path)
+		}
+	}
+
+	code := 0
+	loaded, err := loadLayers(sources, configFlags.format)
+	if err != nil {
+		report.Issues = append(report.Issues, loadIssues(err)...)
+		code = 2
+	} else {
+		for _, err := range loaded.Stale {
+			report.Issues = append(report.Issues, config.Issue{
+				Severity: config.SeverityWarning,
+				Code:     "stale",
+				Message:  err.Error(),
+			})
+		}
+		for _, conflict := range loaded.Conflicts {
+			report.Issues = append(report.Issues, config.Issue{
+				Severity: config.SeverityWarning,
+				Code:     "layer-" + conflict.Kind,
+				Command:  conflict.Command,
+				Message:  conflict.String(),
+			})
+		}
+		report.Issues = append(report.Issues, config.Lint(loaded.Config)...)
+	}
+
+	for _, issue := range report.Issues {
+		if issue.Severity == config.SeverityError {
+			report.Errors++
+		} else {
+			report.Warnings++
+		}
+	}
+	report.Valid = report.Errors == 0 && (!*strict || report.Warnings == 0)
+	if code == 0 && !report.Valid {
+		code = 1
+	}
+
+	encoder := json.NewEncoder(out)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(report); err != nil {
+		_, _ = fmt.Fprintf(errOut, "Error writing report: %v\n", err)
+		return 2
+	}
+
+	return code
+}
+
+// loadIssues describes a failed load, with one issue per duplicate
+// definition when fragments collide
+func loadIssues(err error) []config.Issue {
+	errs := []error{err}
+	if joined, ok := err.(interface{ Unwrap() []error }); ok {
+		errs = joined.Unwrap()
+	}
+
+	var issues []config.Issue
+	for _, err := range errs {
+		issue := config.Issue{Severity: config.SeverityError, Code: "load", Message: err.Error()}
+		var dupErr *config.DuplicateError
+		if errors.As(err, &dupErr) {
+			issue.Code = "duplicate"
+		}
+		issues = append(issues, issue)
+	}
+	return issues
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// writeTestConfig writes a config file into a temporary directory
+func writeTestConfig(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "commands.json")
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+	return path
+}
+
+func TestRunValidate(t *testing.T) {
+	tests := []struct {
+		name     string
+		args     func(path string) []string
+		config   string
+		code     int
+		errors   int
+		warnings int
+	}{
+		{
+			name:   "valid config",
+			config: `{"commands": [{"name": "google", "url": "https://google.com/search?q={{.Query}}", "default": true}]}`,
+			code:   0,
+		},
+		{
+			name:     "warnings only",
+			config:   `{"commands": [{"name": "home", "url": "https://example.com", "requiresQuery": true}]}`,
+			code:     0,
+			warnings: 1,
+		},
+		{
+			name:     "warnings with strict",
+			args:     func(path string) []string { return []string{"-strict", path} },
+			config:   `{"commands": [{"name": "home", "url": "https://example.com", "requiresQuery": true}]}`,
+			code:     1,
+			warnings: 1,
+		},
+		{
+			name:   "errors",
+			config: `{"commands": [{"name": "a", "url": "https://a.com", "default": true}, {"name": "b", "aliases": ["a"], "url": "", "default": true}]}`,
+			code:   1,
+			errors: 3,
+		},
+		{
+			name:   "unparseable",
+			config: `{"commands": [`,
+			code:   2,
+			errors: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := writeTestConfig(t, tt.config)
+			args := []string{path}
+			if tt.args != nil {
+				args = tt.args(path)
+			}
+
+			var out, errOut bytes.Buffer
+			code := runValidate(args, envFunc(nil), &out, &errOut)
+			if code != tt.code {
+				t.Errorf("Expected exit code %d, got %d", tt.code, code)
+			}
+
+			var report validateReport
+			if err := json.Unmarshal(out.Bytes(), &report); err != nil {
+				t.Fatalf("Expected JSON report, got %q: %v", out.String(), err)
+			}
+			if len(report.Files) != 1 || report.Files[0] != path {
+				t.Errorf("Expected files [%q], got %q", path, report.Files)
+			}
+			if report.Errors != tt.errors || report.Warnings != tt.warnings {
+				t.Errorf("Expected %d errors and %d warnings, got %+v", tt.errors, tt.warnings, report)
+			}
+			if report.Valid != (tt.code == 0) {
+				t.Errorf("Expected valid=%v, got %v", tt.code == 0, report.Valid)
+			}
+		})
+	}
+}